@@ -38,11 +38,11 @@
 #endif
 
 namespace jane {
-jane::Slice<jane::Str> command_line_args;
-void setup_command_line_args(int argc, char *argv[]) noexcept;
-jane::Str executable(void) noexcept;
+inline jane::Slice<jane::Str> command_line_args;
+inline void setup_command_line_args(int argc, char *argv[]) noexcept;
+inline jane::Str executable(void) noexcept;
 
-void setup_command_line_args(int argc, char *argv[]) noexcept {
+inline void setup_command_line_args(int argc, char *argv[]) noexcept {
 #ifdef OS_WINDOWS
   const LPWSTR cmdl{GetCommandLineW()};
   LPWSTR *argvw{CommandLineToArgvW(cmdl, &argc)};
@@ -63,7 +63,7 @@ void setup_command_line_args(int argc, char *argv[]) noexcept {
   argvw = nullptr;
 #endif
 }
-jane::Str executable(void) noexcept {
+inline jane::Str executable(void) noexcept {
 #if defined(OS_DARWIN)
   char buff[PATH_MAX];
   uint32_t buff_size{PATH_MAX};
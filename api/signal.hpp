@@ -28,8 +28,8 @@
 namespace jane {
 typedef int Signal;
 
-void set_sig_handler(void (*handler)(int sig)) noexcept;
-void signal_handler(int signal) noexcept;
+inline void set_sig_handler(void (*handler)(int sig)) noexcept;
+inline void signal_handler(int signal) noexcept;
 
 #if defined(OS_WINDOWS)
 constexpr jane::Signal SIG_HUP{0x1};
@@ -116,7 +116,7 @@ constexpr jane::Signal SIG_XCPU{0x18};
 constexpr jane::Signal SIG_XFSZ{0x19};
 #endif
 
-void set_sig_handler(void (*handler)(int _sig)) noexcept {
+inline void set_sig_handler(void (*handler)(int _sig)) noexcept {
 #if defined(OS_WINDOWS)
   std::signal(jane::SIG_HUP, handler);
   std::signal(jane::SIG_INT, handler);
@@ -203,7 +203,7 @@ void set_sig_handler(void (*handler)(int _sig)) noexcept {
 #endif
 }
 
-void signal_handler(int signal) noexcept {
+inline void signal_handler(int signal) noexcept {
   jane::print("program terminated with signal: ");
   jane::println(signal);
   std::exit(signal);
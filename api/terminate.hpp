@@ -43,11 +43,11 @@ struct Error {
   }
 };
 
-void terminate_handler(void) noexcept;
+inline void terminate_handler(void) noexcept;
 
-jane::Trait<Error> exception_to_error(const jane::Exception &exception);
+inline jane::Trait<Error> exception_to_error(const jane::Exception &exception);
 
-void terminate_handler(void) noexcept {
+inline void terminate_handler(void) noexcept {
   try {
     std::rethrow_exception(std::current_exception());
   } catch (const jane::Exception &e) {
@@ -56,7 +56,7 @@ void terminate_handler(void) noexcept {
   }
 }
 
-jane::Trait<Error> exception_to_error(const jane::Exception &exception) {
+inline jane::Trait<Error> exception_to_error(const jane::Exception &exception) {
   struct PanicError : public Error {
     jane::Str message;
     jane::Str error(void) { return this->message; }
@@ -36,12 +36,14 @@ constexpr signed int UTF16_MAX_RUNE{1114111};
 
 inline jane::I32 utf16_decode_rune(const jane::I32 r1,
                                    const jane::I32 r2) noexcept;
-jane::Slice<jane::I32> utf16_decode(const jane::Slice<jane::I32> s) noexcept;
-jane::Str utf16_to_utf8_str(const wchar_t wstr, const std::size_t len) noexcept;
-std::tuple<jane::I32, jane::I32> utf16_encode_rune(jane::I32 r) noexcept;
-jane::Slice<jane::U16>
+inline jane::Slice<jane::I32>
+utf16_decode(const jane::Slice<jane::I32> s) noexcept;
+inline jane::Str utf16_to_utf8_str(const wchar_t wstr,
+                                   const std::size_t len) noexcept;
+inline std::tuple<jane::I32, jane::I32> utf16_encode_rune(jane::I32 r) noexcept;
+inline jane::Slice<jane::U16>
 utf16_encode(const jane::Slice<jane::I32> &runes) noexcept;
-jane::Slice<jane::U16> utf16_from_str(const jane::Str &s) noexcept;
+inline jane::Slice<jane::U16> utf16_from_str(const jane::Str &s) noexcept;
 
 inline jane::I32 utf16_decode_rune(const jane::I32 r1,
                                    const jane::I32 r2) noexcept {
@@ -53,7 +55,8 @@ inline jane::I32 utf16_decode_rune(const jane::I32 r1,
   return jane::UTF16_REPLACEMENT_CHAR;
 }
 
-jane::Slice<jane::I32> utf16_decode(const jane::Slice<jane::U16> &s) noexcept {
+inline jane::Slice<jane::I32>
+utf16_decode(const jane::Slice<jane::U16> &s) noexcept {
   jane::Slice<jane::I32> a{jane::Slice<jane::I32>::alloc(s.len())};
   jane::Int n{0};
   for (jane::Int i{0}; i < s.len(); ++i) {
@@ -74,8 +77,8 @@ jane::Slice<jane::I32> utf16_decode(const jane::Slice<jane::U16> &s) noexcept {
   return a.slice(0, n);
 }
 
-jane::Str utf16_to_utf8_str(const wchar_t *wstr,
-                            const std::size_t len) noexcept {
+inline jane::Str utf16_to_utf8_str(const wchar_t *wstr,
+                                   const std::size_t len) noexcept {
   jane::Slice<jane::U16> code_page{jane::Slice<jane::U16>::alloc(len)};
   for (jane::Int i{0}; i < len; ++i) {
     code_page[i] = static_cast<jane::U16>(wstr[i]);
@@ -83,7 +86,8 @@ jane::Str utf16_to_utf8_str(const wchar_t *wstr,
   return static_cast<jane::Str>(jane::utf16_decode(code_page));
 }
 
-std::tuple<jane::I32, jane::I32> utf16_encode_rune(jane::I32 r) noexcept {
+inline std::tuple<jane::I32, jane::I32>
+utf16_encode_rune(jane::I32 r) noexcept {
   if (r < jane::UTF16_SURR_SELF || r > jane::UTF16_MAX_RUNE) {
     return std::make_tuple<jane::I32, jane::I32>(jane::UTF16_REPLACEMENT_CHAR,
                                                  jane::UTF16_REPLACEMENT_CHAR);
@@ -93,7 +97,7 @@ std::tuple<jane::I32, jane::I32> utf16_encode_rune(jane::I32 r) noexcept {
       jane::UTF16_SURR1 + (r >> 10) & 0x3ff, jane::UTF16_SURR2 + r & 0x3ff);
 }
 
-jane::Slice<jane::U16>
+inline jane::Slice<jane::U16>
 utf16_encode(const jane::Slice<jane::I32> &runes) noexcept {
   jane::Int n{runes.len()};
   for (const jane::I32 v : runes) {
@@ -122,8 +126,8 @@ utf16_encode(const jane::Slice<jane::I32> &runes) noexcept {
   return a.slice(0, n);
 }
 
-jane::Slice<jane::U16> utf16_append_rune(jane::Slice<jane::U16> &a,
-                                         const jane::I32 &r) noexcept {
+inline jane::Slice<jane::U16>
+utf16_append_rune(jane::Slice<jane::U16> &a, const jane::I32 &r) noexcept {
   if (0 <= r & r < jane::UTF16_SURR1 | jane::UTF16_SURR3 <= r &&
       r < jane::UTF16_SURR_SELF) {
     a.push(static_cast<jane::U16>(r));
@@ -139,7 +143,7 @@ jane::Slice<jane::U16> utf16_append_rune(jane::Slice<jane::U16> &a,
   return a;
 }
 
-jane::Slice<jane::U16> utf16_from_str(const jane::Str &s) noexcept {
+inline jane::Slice<jane::U16> utf16_from_str(const jane::Str &s) noexcept {
   constexpr char NULL_TERMINATOR = '\x00';
   jane::Slice<jane::U16> buff{nullptr};
   jane::Slice<jane::I32> runes{static_cast<jane::Slice<jane::I32>>(s)};
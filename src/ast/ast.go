@@ -331,7 +331,7 @@ func (f *Fn) OutId() string {
 	if f.Receiver != nil {
 		return "_method_" + f.Id
 	}
-	return build.OutId(f.Id, f.Token.File.Addr())
+	return build.OutId(f.Id, f.Token.File.Id())
 }
 
 func (f *Fn) DefineString() string {
@@ -697,7 +697,7 @@ func (s *Struct) OutId() string {
 	if s.CppLinked {
 		return s.Id
 	}
-	return build.OutId(s.Id, s.Token.File.Addr())
+	return build.OutId(s.Id, s.Token.File.Id())
 }
 
 func (s *Struct) GetGenerics() []Type {
@@ -780,7 +780,7 @@ func (t *Trait) FindFunc(id string) *Fn {
 }
 
 func (t *Trait) OutId() string {
-	return build.OutId(t.Id, t.Token.File.Addr())
+	return build.OutId(t.Id, t.Token.File.Id())
 }
 
 type TypeAlias struct {
@@ -1007,7 +1007,7 @@ func (dt Type) String() (s string) {
 		if dt.Generic {
 			return build.AsId(dt.Kind)
 		}
-		return build.OutId(dt.Kind, dt.Token.File.Addr())
+		return build.OutId(dt.Kind, dt.Token.File.Id())
 	case enum_t:
 		e := dt.Tag.(*Enum)
 		return e.DataType.String()
@@ -1065,7 +1065,7 @@ func (dt *Type) trait_str() string {
 	id, _ := dt.KindId()
 	cpp.WriteString(build.AsTypeId("trait"))
 	cpp.WriteByte('<')
-	cpp.WriteString(build.OutId(id, dt.Token.File.Addr()))
+	cpp.WriteString(build.OutId(id, dt.Token.File.Id()))
 	cpp.WriteByte('>')
 	return cpp.String()
 }
@@ -1133,6 +1133,8 @@ type UseDecl struct {
 	FullUse    bool
 	Selectors  []lexer.Token
 	Defines    *Defmap
+	// Use declarations of files of used package.
+	PackageUses []*UseDecl
 }
 
 type Var struct {
@@ -1174,7 +1176,7 @@ func (v *Var) OutId() string {
 	case v.IsField:
 		return "_field_" + v.Id
 	default:
-		return build.OutId(v.Id, v.Token.File.Addr())
+		return build.OutId(v.Id, v.Token.File.Id())
 	}
 }
 
@@ -218,3 +218,54 @@ func (dm *Defmap) PushDefines(dest *Defmap) {
 	dest.Globals = append(dest.Globals, dm.Globals...)
 	dest.Fns = append(dest.Fns, dm.Fns...)
 }
+
+// Returns identifiers of defines accessible from file.
+// Defines of side are included.
+func (dm *Defmap) Ids(f *lexer.File) []string {
+	var ids []string
+	for _, s := range dm.Structs {
+		if s != nil && IsAccessable(f, s.Token.File, s.Pub) {
+			ids = append(ids, s.Id)
+		}
+	}
+	for _, t := range dm.Traits {
+		if t != nil && IsAccessable(f, t.Token.File, t.Pub) {
+			ids = append(ids, t.Id)
+		}
+	}
+	for _, e := range dm.Enums {
+		if e != nil && IsAccessable(f, e.Token.File, e.Pub) {
+			ids = append(ids, e.Id)
+		}
+	}
+	for _, t := range dm.Types {
+		if t != nil && IsAccessable(f, t.Token.File, t.Pub) {
+			ids = append(ids, t.Id)
+		}
+	}
+	for _, fn := range dm.Fns {
+		if fn != nil && IsAccessable(f, fn.Token.File, fn.Public) {
+			ids = append(ids, fn.Id)
+		}
+	}
+	for _, g := range dm.Globals {
+		if g != nil && g.DataType.Id != void_t && IsAccessable(f, g.Token.File, g.Public) {
+			ids = append(ids, g.Id)
+		}
+	}
+	if dm.Side != nil {
+		ids = append(ids, dm.Side.Ids(f)...)
+	}
+	return ids
+}
+
+// Returns identifiers of namespaces.
+func (dm *Defmap) NsIds() []string {
+	ids := make([]string, 0, len(dm.Namespaces))
+	for _, ns := range dm.Namespaces {
+		if ns != nil {
+			ids = append(ids, ns.Id)
+		}
+	}
+	return ids
+}
@@ -26,13 +26,7 @@ import (
 )
 
 func compiler_err(t lexer.Token, key string, args ...any) build.Log {
-	return build.Log{
-		Type:   build.ERR,
-		Row:    t.Row,
-		Column: t.Column,
-		Path:   t.File.Path(),
-		Text:   build.Errorf(key, args...),
-	}
+	return build.Err(t.Row, t.Column, t.EndRow(), t.EndColumn(), t.File.Path(), key, args...)
 }
 
 func Range(i *int, open string, close string, toks []lexer.Token) []lexer.Token {
@@ -78,6 +78,40 @@ var type_map = map[uint8]string{
 	unsafe_t:  lexer.KND_UNSAFE,
 }
 
+// Fixed width C types of primitive types, used by library interfaces.
+var c_type_map = map[uint8]string{
+	void_t:    "void",
+	i8_t:      "int8_t",
+	i16_t:     "int16_t",
+	i32_t:     "int32_t",
+	i64_t:     "int64_t",
+	u8_t:      "uint8_t",
+	u16_t:     "uint16_t",
+	u32_t:     "uint32_t",
+	u64_t:     "uint64_t",
+	bool_t:    "bool",
+	f32_t:     "float",
+	f64_t:     "double",
+	int_t:     "intptr_t",
+	uint_t:    "uintptr_t",
+	uintptr_t: "uintptr_t",
+}
+
+// Returns type as C type, reports false if type is not primitive.
+// Strings, slices, pointers and structures have no C type,
+// they are defined by runtime.
+func (dt Type) CType() (string, bool) {
+	dt.SetToOriginal()
+	if dt.MultiTyped || dt.CppLinked || dt.Modifiers() != "" {
+		return "", false
+	}
+	if _, ok := dt.Tag.(*Struct); ok {
+		return "", false
+	}
+	t, ok := c_type_map[dt.Id]
+	return t, ok
+}
+
 func cpp_id(t uint8) string {
 	if t == void_t || t == unsafe_t {
 		return "void"
@@ -22,7 +22,6 @@ package build
 
 import (
 	"path/filepath"
-	"runtime"
 	"strings"
 
 	"github.com/DeRuneLabs/jane"
@@ -31,11 +30,13 @@ import (
 const (
 	ATTR_CDEF    = "cdef"
 	ATTR_TYPEDEF = "typedef"
+	ATTR_TEST    = "test"
 )
 
 var ATTRS = [...]string{
 	ATTR_CDEF,
 	ATTR_TYPEDEF,
+	ATTR_TEST,
 }
 
 func check_os(arg string) (ok bool, exist bool) {
@@ -43,13 +44,13 @@ func check_os(arg string) (ok bool, exist bool) {
 	exist = true
 	switch arg {
 	case OS_WINDOWS:
-		ok = IsWindows(runtime.GOOS)
+		ok = IsWindows(OS)
 	case OS_DARWIN:
-		ok = IsDarwin(runtime.GOOS)
+		ok = IsDarwin(OS)
 	case OS_LINUX:
-		ok = IsLinux(runtime.GOOS)
+		ok = IsLinux(OS)
 	case OS_UNIX:
-		ok = IsUnix(runtime.GOOS)
+		ok = IsUnix(OS)
 	default:
 		ok = true
 		exist = false
@@ -62,17 +63,17 @@ func check_arch(arg string) (ok bool, exist bool) {
 	exist = false
 	switch arg {
 	case ARCH_I386:
-		ok = IsI386(runtime.GOARCH)
+		ok = IsI386(ARCH)
 	case ARCH_AMD64:
-		ok = IsAmd64(runtime.GOARCH)
+		ok = IsAmd64(ARCH)
 	case ARCH_ARM:
-		ok = IsArm(runtime.GOARCH)
+		ok = IsArm(ARCH)
 	case ARCH_ARM64:
-		ok = IsArm64(runtime.GOARCH)
+		ok = IsArm64(ARCH)
 	case ARCH_64Bit:
-		ok = IsX64(runtime.GOARCH)
+		ok = IsX64(ARCH)
 	case ARCH_32Bit:
-		ok = IsX32(runtime.GOARCH)
+		ok = IsX32(ARCH)
 	default:
 		ok = true
 		exist = false
@@ -36,21 +36,16 @@ func AsId(id string) string {
 	return "_" + id
 }
 
-func get_ptr_as_id(ptr uintptr) string {
-	address := "_" + strconv.FormatUint(uint64(ptr), 16)
-	for i, r := range address {
-		if r != '0' {
-			address = address[i:]
-			break
-		}
-	}
-	return address
+func get_file_id(file_id uint64) string {
+	return "_" + strconv.FormatUint(file_id, 16)
 }
 
-func OutId(id string, ptr uintptr) string {
-	if ptr != 0 {
+// Returns mangled identifier of id.
+// Identifier is qualified by file_id if it is not zero.
+func OutId(id string, file_id uint64) string {
+	if file_id != 0 {
 		var out strings.Builder
-		out.WriteString(get_ptr_as_id(ptr))
+		out.WriteString(get_file_id(file_id))
 		out.WriteByte('_')
 		out.WriteString(id)
 		return out.String()
@@ -50,6 +50,8 @@ var ERRORS = map[string]string{
 	`fn_have_ret`:                              `@ function cannot have return type`,
 	`fn_have_parameters`:                       `@ function cannot have parameter(s)`,
 	`fn_is_unsafe`:                             `@ function cannot unsafe`,
+	`test_fn_is_method`:                        `test function cannot be method: @`,
+	`test_fn_has_generics`:                     `test function cannot have generics: @`,
 	`require_return_value`:                     `return statements of non-void functions should have return value`,
 	`void_function_return_value`:               `void functions is cannot returns any value`,
 	`bitshift_must_unsigned`:                   `bit shifting value is must be unsigned`,
@@ -122,6 +124,10 @@ var ERRORS = map[string]string{
 	`obj_have_not_id`:                          `object is not have sub field in this identifier: @`,
 	`doc_couldnt_generated`:                    `@: documentation could not generated because Jane source code has an errors`,
 	`declared_but_not_used`:                    `@ declared but not used`,
+	`invalid_lint_key`:                         `diagnostic cannot be allowed or demoted: @`,
+	`too_many_errors`:                          `too many errors, @ more errors are not reported`,
+	`did_you_mean`:                             `did you mean @?`,
+	`previously_declared`:                      `previously declared here`,
 	`expr_not_func_call`:                       `statement must have function call expression`,
 	`label_exist`:                              `label is already exist in this identifier: @`,
 	`label_not_exist`:                          `not exist any label in this identifier: @`,
@@ -156,13 +162,38 @@ var ERRORS = map[string]string{
 	`enum_have_not_field`:                      `enum have not any field: @`,
 	`enum_not_supports_as_generic`:             `enum types not supported as generic type`,
 	`duplicate_match_type`:                     `type is already checked: @`,
+	`format_changes_tokens`:                    `formatting changes tokens, file is not formatted`,
+	`fn_not_exported`:                          `function is not exported, its signature has types without C type: @`,
+	`struct_not_exported`:                      `struct is not exported, its fields have types without C type: @`,
+	`define_not_exported`:                      `public define is not exported by library: @`,
+}
+
+// Default levels of diagnostics that are not reported as error.
+// Levels of these diagnostics are configurable.
+var LEVELS = map[string]uint8{
+	`declared_but_not_used`: WARNING,
+	`fn_not_exported`:       WARNING,
+	`struct_not_exported`:   WARNING,
+	`define_not_exported`:   WARNING,
 }
 
 func Errorf(key string, args ...any) string {
-	fmt := ERRORS[key]
+	fmt := Message(key)
 	return apply_fmt(fmt, args...)
 }
 
+// Returns formatted arguments of error message.
+func ArgsOf(args ...any) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		strs[i] = arg_to_str(arg)
+	}
+	return strs
+}
+
 func arg_to_str(arg any) string {
 	switch t := arg.(type) {
 	case string:
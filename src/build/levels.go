@@ -0,0 +1,123 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package build
+
+import (
+	"sort"
+	"strconv"
+)
+
+// Key that selects all diagnostics with WARNING level.
+const LINT_WARNINGS = "warnings"
+
+var (
+	level_overrides = map[string]uint8{}
+	allowed_keys    = map[string]bool{}
+	deny_warnings   = false
+)
+
+// Reports whether level of diagnostic key is configurable.
+func IsLint(key string) bool {
+	_, ok := LEVELS[key]
+	return ok || key == LINT_WARNINGS
+}
+
+// Returns level of diagnostic key.
+func LevelOf(key string) uint8 {
+	if level, ok := level_overrides[key]; ok {
+		return level
+	}
+	level, ok := LEVELS[key]
+	if !ok {
+		return ERROR
+	}
+	if level == WARNING && deny_warnings {
+		return ERROR
+	}
+	return level
+}
+
+// Reports diagnostic key as error.
+// With LINT_WARNINGS, all warnings are reported as error.
+// Reports false if key is not exist.
+func Deny(key string) bool {
+	if _, ok := ERRORS[key]; !ok && key != LINT_WARNINGS {
+		return false
+	}
+	delete(allowed_keys, key)
+	if key == LINT_WARNINGS {
+		deny_warnings = true
+		return true
+	}
+	level_overrides[key] = ERROR
+	return true
+}
+
+// Reports diagnostic key as warning.
+// Reports false if key is not configurable.
+func Warn(key string) bool {
+	if !IsLint(key) {
+		return false
+	}
+	delete(allowed_keys, key)
+	if key == LINT_WARNINGS {
+		deny_warnings = false
+		return true
+	}
+	level_overrides[key] = WARNING
+	return true
+}
+
+// Silences diagnostic key.
+// With LINT_WARNINGS, all warnings are silenced.
+// Reports false if key is not configurable.
+func Allow(key string) bool {
+	if !IsLint(key) {
+		return false
+	}
+	allowed_keys[key] = true
+	return true
+}
+
+// Reports whether diagnostic key is silenced.
+func IsAllowed(key string) bool {
+	if allowed_keys[key] {
+		return true
+	}
+	return allowed_keys[LINT_WARNINGS] && LevelOf(key) == WARNING
+}
+
+// Returns configured levels as sorted strings.
+// Useful for keys of caches that depend on diagnostics.
+func LevelConfig() []string {
+	var config []string
+	for key, level := range level_overrides {
+		config = append(config, key+"="+strconv.Itoa(int(level)))
+	}
+	for key := range allowed_keys {
+		config = append(config, key+"=allow")
+	}
+	if deny_warnings {
+		config = append(config, LINT_WARNINGS+"=deny")
+	}
+	sort.Strings(config)
+	return config
+}
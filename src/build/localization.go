@@ -0,0 +1,183 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package build
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/DeRuneLabs/jane"
+)
+
+// Extension of message catalog files.
+const CATALOG_EXT = ".json"
+
+// Locale of loaded message catalog, empty for English.
+var LOCALE string
+
+// Messages of loaded locale by error keys.
+// Keys that are not exist use English messages of ERRORS.
+var CATALOG map[string]string
+
+// Locale of LANG environment variable is loaded on first use of
+// messages, if no locale is loaded by LoadLocale before.
+var locale_once sync.Once
+
+// Loads catalog of LANG environment variable.
+// Invalid catalog is not fatal, English messages are used with warning.
+func load_env_locale() {
+	_, err := load_locale(jane.LOCALIZATION_PATH, os.Getenv("LANG"))
+	if err != nil {
+		println("warning: invalid message catalog, English messages are used: " + err.Error())
+	}
+}
+
+// Returns locale of messages, empty for English.
+func Locale() string {
+	locale_once.Do(load_env_locale)
+	return LOCALE
+}
+
+// Returns message format of key, English if not translated.
+func Message(key string) string {
+	locale_once.Do(load_env_locale)
+	if msg, ok := CATALOG[key]; ok {
+		return msg
+	}
+	return ERRORS[key]
+}
+
+// Returns locale names to try for language, most specific first.
+// Language is in form of LANG environment variable, like tr_TR.UTF-8.
+// Returns nil for English and POSIX locales.
+func LocalesOf(lang string) []string {
+	if i := strings.IndexAny(lang, ".@"); i != -1 {
+		lang = lang[:i]
+	}
+	switch lang {
+	case "", "C", "POSIX":
+		return nil
+	}
+	locales := []string{lang}
+	if i := strings.IndexAny(lang, "_-"); i != -1 {
+		locales = append(locales, lang[:i])
+	}
+	if locales[len(locales)-1] == "en" {
+		return nil
+	}
+	return locales
+}
+
+// Returns message catalog of file.
+func LoadCatalog(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var catalog map[string]string
+	err = json.Unmarshal(data, &catalog)
+	if err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+// Returns path of catalog for language in directory.
+// Reports false if there is no catalog for language.
+func FindCatalog(dir string, lang string) (string, bool) {
+	for _, locale := range LocalesOf(lang) {
+		path := filepath.Join(dir, locale+CATALOG_EXT)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// Loads message catalog of language from directory.
+// English messages are used if language is English
+// or has no catalog, reports false in later case.
+// Locale of LANG environment variable is not loaded after.
+func LoadLocale(dir string, lang string) (bool, error) {
+	locale_once.Do(func() {})
+	return load_locale(dir, lang)
+}
+
+func load_locale(dir string, lang string) (bool, error) {
+	LOCALE = ""
+	CATALOG = nil
+	if LocalesOf(lang) == nil {
+		return true, nil
+	}
+	path, ok := FindCatalog(dir, lang)
+	if !ok {
+		return false, nil
+	}
+	catalog, err := LoadCatalog(path)
+	if err != nil {
+		return false, err
+	}
+	LOCALE = strings.TrimSuffix(filepath.Base(path), CATALOG_EXT)
+	CATALOG = catalog
+	return true, nil
+}
+
+// Returns sorted keys of ERRORS that are not exist in catalog.
+func Untranslated(catalog map[string]string) []string {
+	var keys []string
+	for key := range ERRORS {
+		if _, ok := catalog[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Returns sorted keys of catalog that are not exist in ERRORS.
+func UnknownKeys(catalog map[string]string) []string {
+	var keys []string
+	for key := range catalog {
+		if _, ok := ERRORS[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Returns sorted keys of catalog that have different count of
+// placeholders than English message.
+func MismatchedKeys(catalog map[string]string) []string {
+	var keys []string
+	for key, msg := range catalog {
+		en, ok := ERRORS[key]
+		if ok && strings.Count(msg, "@") != strings.Count(en, "@") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -28,16 +28,170 @@ import (
 const FLAT_ERR uint8 = 0
 const ERR uint8 = 1
 
+// Severity levels of logs.
+// Only logs with ERROR level block compilation.
+const (
+	ERROR   uint8 = 0
+	WARNING uint8 = 1
+	NOTE    uint8 = 2
+)
+
 type Log struct {
-	Type   uint8
-	Row    int
-	Column int
-	Path   string
-	Text   string
+	Type      uint8
+	Level     uint8
+	Row       int
+	Column    int
+	EndRow    int
+	EndColumn int
+	Path      string
+	Key       string
+	Args      []string
+	Text      string
+	Notes     []Note
+}
+
+// Note is secondary message of log, like suggestion or related location.
+// Note has location if Path is not empty.
+type Note struct {
+	Row       int
+	Column    int
+	EndColumn int
+	Path      string
+	Key       string
+	Args      []string
+	Text      string
+}
+
+// Returns note of key at position.
+func NoteAt(row int, column int, end_column int, path string, key string, args ...any) Note {
+	return Note{
+		Row:       row,
+		Column:    column,
+		EndColumn: end_column,
+		Path:      path,
+		Key:       key,
+		Args:      ArgsOf(args...),
+		Text:      Errorf(key, args...),
+	}
+}
+
+// Returns note of key without position.
+func FlatNote(key string, args ...any) Note {
+	return Note{
+		Key:  key,
+		Args: ArgsOf(args...),
+		Text: Errorf(key, args...),
+	}
+}
+
+func (n *Note) String() string {
+	if n.Path == "" {
+		return "note: " + n.Text
+	}
+	return n.Path + ":" + strconv.Itoa(n.Row) + ":" + strconv.Itoa(n.Column) + " note: " + n.Text
+}
+
+// Returns error log of key at span.
+func Err(row int, column int, end_row int, end_column int, path string, key string, args ...any) Log {
+	return Log{
+		Type:      ERR,
+		Level:     LevelOf(key),
+		Row:       row,
+		Column:    column,
+		EndRow:    end_row,
+		EndColumn: end_column,
+		Path:      path,
+		Key:       key,
+		Args:      ArgsOf(args...),
+		Text:      Errorf(key, args...),
+	}
+}
+
+// Returns flat error log of key.
+func FlatErr(key string, args ...any) Log {
+	return Log{
+		Type:  FLAT_ERR,
+		Level: LevelOf(key),
+		Key:   key,
+		Args:  ArgsOf(args...),
+		Text:  Errorf(key, args...),
+	}
+}
+
+// Returns severity name of log.
+func (l *Log) Severity() string {
+	switch l.Level {
+	case WARNING:
+		return "warning"
+	case NOTE:
+		return "note"
+	default:
+		return "error"
+	}
+}
+
+// Returns severity level of name, reports whether name is valid.
+func LevelOfSeverity(name string) (uint8, bool) {
+	switch name {
+	case "error":
+		return ERROR, true
+	case "warning":
+		return WARNING, true
+	case "note":
+		return NOTE, true
+	default:
+		return ERROR, false
+	}
+}
+
+// Returns text of log with severity prefix.
+// Errors are not prefixed.
+func (l *Log) text() string {
+	if l.Level == ERROR {
+		return l.Text
+	}
+	return l.Severity() + ": " + l.Text
+}
+
+// Reports whether logs has any log with ERROR level.
+func HasErrors(logs []Log) bool {
+	for _, l := range logs {
+		if l.Level == ERROR {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns logs with at most max errors.
+// Warnings and notes are not counted. If any error is omitted,
+// note that reports count of omitted errors is appended.
+// Logs are not capped if max is zero or negative.
+func CapErrors(logs []Log, max int) []Log {
+	if max <= 0 {
+		return logs
+	}
+	capped := make([]Log, 0, len(logs))
+	n := 0
+	for _, l := range logs {
+		if l.Level == ERROR {
+			n++
+			if n > max {
+				continue
+			}
+		}
+		capped = append(capped, l)
+	}
+	if n <= max {
+		return logs
+	}
+	note := FlatErr("too_many_errors", strconv.Itoa(n-max))
+	note.Level = NOTE
+	return append(capped, note)
 }
 
 func (l *Log) flat_err() string {
-	return l.Text
+	return l.text()
 }
 
 func (l *Log) err() string {
@@ -48,16 +202,20 @@ func (l *Log) err() string {
 	log.WriteByte(':')
 	log.WriteString(strconv.Itoa(l.Column))
 	log.WriteByte(' ')
-	log.WriteString(l.Text)
+	log.WriteString(l.text())
 	return log.String()
 }
 
 func (l Log) String() string {
+	var s string
 	switch l.Type {
 	case FLAT_ERR:
-		return l.flat_err()
+		s = l.flat_err()
 	case ERR:
-		return l.err()
+		s = l.err()
+	}
+	for _, n := range l.Notes {
+		s += "\n" + n.String()
 	}
-	return ""
+	return s
 }
@@ -0,0 +1,68 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package build
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCapErrors(t *testing.T) {
+	e := func(key string) Log { return Log{Type: FLAT_ERR, Level: ERROR, Key: key} }
+	w := func(key string) Log { return Log{Type: FLAT_ERR, Level: WARNING, Key: key} }
+	more := func(n string) Log {
+		return Log{Type: FLAT_ERR, Level: NOTE, Key: "too_many_errors", Args: []string{n},
+			Text: Errorf("too_many_errors", n)}
+	}
+	tests := []struct {
+		name string
+		logs []Log
+		max  int
+		want []Log
+	}{
+		{"no limit", []Log{e("a"), e("b")}, 0, []Log{e("a"), e("b")}},
+		{"negative limit", []Log{e("a"), e("b")}, -1, []Log{e("a"), e("b")}},
+		{"empty", nil, 2, nil},
+		{"under limit", []Log{e("a")}, 2, []Log{e("a")}},
+		{"at limit", []Log{e("a"), e("b")}, 2, []Log{e("a"), e("b")}},
+		{"over limit", []Log{e("a"), e("b"), e("c")}, 1, []Log{e("a"), more("2")}},
+		{
+			"warnings are not counted",
+			[]Log{w("w1"), e("a"), w("w2"), e("b"), e("c")},
+			2,
+			[]Log{w("w1"), e("a"), w("w2"), e("b"), more("1")},
+		},
+		{
+			"warnings after limit are kept",
+			[]Log{e("a"), e("b"), w("w")},
+			1,
+			[]Log{e("a"), w("w"), more("1")},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := CapErrors(test.logs, test.max)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
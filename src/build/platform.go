@@ -20,6 +20,12 @@
 
 package build
 
+import (
+	"runtime"
+
+	"github.com/DeRuneLabs/jane/types"
+)
+
 const (
 	OS_WINDOWS = "windows"
 	OS_LINUX   = "linux"
@@ -62,6 +68,112 @@ const (
 	goarch_arm64 = "arm64"
 )
 
+// Target operating system and architecture of compilation.
+// Defaults to the host, set by SetTarget for cross-compilation.
+var (
+	OS   = runtime.GOOS
+	ARCH = runtime.GOARCH
+)
+
+// Returns goos form of distos name, empty if not supported.
+func GoosOf(os string) string {
+	switch os {
+	case OS_WINDOWS:
+		return goos_windows
+	case OS_DARWIN:
+		return goos_darwin
+	case OS_LINUX:
+		return goos_linux
+	default:
+		return ""
+	}
+}
+
+// Returns goarch form of distarch name, empty if not supported.
+func GoarchOf(arch string) string {
+	switch arch {
+	case ARCH_I386:
+		return goarch_i386
+	case ARCH_AMD64:
+		return goarch_amd64
+	case ARCH_ARM:
+		return goarch_arm
+	case ARCH_ARM64:
+		return goarch_arm64
+	default:
+		return ""
+	}
+}
+
+// Sets target of compilation by distos and distarch names.
+// Empty names keep the current target.
+// Bit-size of int and uint kinds follows the target architecture.
+// Reports whether names are supported.
+func SetTarget(os string, arch string) bool {
+	if os != "" {
+		os = GoosOf(os)
+		if os == "" {
+			return false
+		}
+	}
+	if arch != "" {
+		arch = GoarchOf(arch)
+		if arch == "" {
+			return false
+		}
+	}
+	if os != "" {
+		OS = os
+	}
+	if arch != "" {
+		ARCH = arch
+	}
+	if IsX32(ARCH) {
+		types.Set_bitsize(0b00100000)
+	} else {
+		types.Set_bitsize(0b01000000)
+	}
+	return true
+}
+
+// Reports whether target differs from host.
+func IsCross() bool {
+	return OS != runtime.GOOS || ARCH != runtime.GOARCH
+}
+
+// Returns target triple of current target for backend compiler.
+func Triple() string {
+	arch := ""
+	switch ARCH {
+	case goarch_i386:
+		arch = "i386"
+		if IsWindows(OS) {
+			arch = "i686"
+		}
+	case goarch_amd64:
+		arch = "x86_64"
+	case goarch_arm:
+		arch = "arm"
+	case goarch_arm64:
+		arch = "aarch64"
+		if IsDarwin(OS) {
+			arch = "arm64"
+		}
+	}
+	switch OS {
+	case goos_windows:
+		return arch + "-pc-windows-gnu"
+	case goos_darwin:
+		return arch + "-apple-darwin"
+	case goos_linux:
+		if IsArm(ARCH) {
+			return arch + "-linux-gnueabihf"
+		}
+		return arch + "-linux-gnu"
+	}
+	return arch
+}
+
 func IsWindows(os string) bool {
 	return os == goos_windows
 }
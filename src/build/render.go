@@ -0,0 +1,185 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package build
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ANSI escape sequences of rendered diagnostics.
+const (
+	ANSI_RESET  = "\x1b[0m"
+	ANSI_BOLD   = "\x1b[1m"
+	ANSI_RED    = "\x1b[1;31m"
+	ANSI_YELLOW = "\x1b[1;33m"
+	ANSI_CYAN   = "\x1b[1;36m"
+	ANSI_BLUE   = "\x1b[1;34m"
+)
+
+// Column width of tab, same as lexer.
+const TAB_WIDTH = 4
+
+// Renderer renders logs with source snippets.
+// Offending token is underlined with caret and tildes.
+type Renderer struct {
+	// Returns source line of path at row, reports false if not exist.
+	Source func(path string, row int) (string, bool)
+	// Enables ANSI colors.
+	Color bool
+}
+
+func (r *Renderer) paint(s string, color string) string {
+	if !r.Color {
+		return s
+	}
+	return color + s + ANSI_RESET
+}
+
+func color_of(level uint8) string {
+	switch level {
+	case WARNING:
+		return ANSI_YELLOW
+	case NOTE:
+		return ANSI_CYAN
+	default:
+		return ANSI_RED
+	}
+}
+
+// Returns log rendered with source snippet.
+// Notes with location are rendered as snippets too,
+// other notes are listed under the log.
+func (r *Renderer) Render(l Log) string {
+	var sb strings.Builder
+	r.header(&sb, l.Severity(), color_of(l.Level), l.Text)
+	gutter := 0
+	if l.Type == ERR {
+		end_column := l.EndColumn
+		if l.EndRow > l.Row {
+			// Span continues on next lines, underline rest of first line.
+			end_column = math.MaxInt
+		}
+		gutter = r.snippet(&sb, l.Path, l.Row, l.Column, end_column, color_of(l.Level))
+	}
+	for _, n := range l.Notes {
+		if n.Path != "" {
+			continue
+		}
+		sb.WriteString(strings.Repeat(" ", gutter+1))
+		sb.WriteString(r.paint("=", ANSI_BLUE))
+		sb.WriteByte(' ')
+		sb.WriteString(r.paint("note", ANSI_BOLD))
+		sb.WriteString(": ")
+		sb.WriteString(n.Text)
+		sb.WriteByte('\n')
+	}
+	for _, n := range l.Notes {
+		if n.Path == "" {
+			continue
+		}
+		r.header(&sb, "note", ANSI_CYAN, n.Text)
+		_ = r.snippet(&sb, n.Path, n.Row, n.Column, n.EndColumn, ANSI_CYAN)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func (r *Renderer) header(sb *strings.Builder, severity string, color string, text string) {
+	sb.WriteString(r.paint(severity, color))
+	sb.WriteString(r.paint(": "+text, ANSI_BOLD))
+	sb.WriteByte('\n')
+}
+
+// Writes location and underlined source line.
+// Returns width of line number gutter.
+func (r *Renderer) snippet(sb *strings.Builder, path string, row int, column int, end_column int, color string) int {
+	num := strconv.Itoa(row)
+	gutter := len(num)
+	sb.WriteString(strings.Repeat(" ", gutter))
+	sb.WriteString(r.paint("--> ", ANSI_BLUE))
+	sb.WriteString(path)
+	sb.WriteByte(':')
+	sb.WriteString(num)
+	sb.WriteByte(':')
+	sb.WriteString(strconv.Itoa(column))
+	sb.WriteByte('\n')
+	if r.Source == nil {
+		return gutter
+	}
+	line, ok := r.Source(path, row)
+	if !ok {
+		return gutter
+	}
+	pad := strings.Repeat(" ", gutter)
+	bar := r.paint("|", ANSI_BLUE)
+	sb.WriteString(pad + " " + bar + "\n")
+	sb.WriteString(r.paint(num, ANSI_BLUE))
+	sb.WriteString(" " + bar + " ")
+	sb.WriteString(expand_tabs(line))
+	sb.WriteByte('\n')
+	start, end := underline_span(line, column, end_column)
+	sb.WriteString(pad + " " + bar + " ")
+	sb.WriteString(strings.Repeat(" ", start))
+	sb.WriteString(r.paint("^"+strings.Repeat("~", end-start-1), color))
+	sb.WriteByte('\n')
+	return gutter
+}
+
+// Returns line with tabs expanded to spaces.
+func expand_tabs(line string) string {
+	return strings.ReplaceAll(line, "\t", strings.Repeat(" ", TAB_WIDTH))
+}
+
+// Returns display cells of columns in line with expanded tabs.
+// Columns are counted like lexer; tab is TAB_WIDTH columns,
+// other runes are columns as many as bytes.
+// Span covers at least one cell.
+func underline_span(line string, column int, end_column int) (start int, end int) {
+	col := 1
+	cell := 0
+	start = -1
+	for _, r := range line {
+		if start == -1 && col >= column {
+			start = cell
+		}
+		if col >= end_column {
+			break
+		}
+		if r == '\t' {
+			col += TAB_WIDTH
+			cell += TAB_WIDTH
+		} else {
+			col += utf8.RuneLen(r)
+			cell++
+		}
+	}
+	if start == -1 {
+		start = cell + column - col
+		cell = start
+	}
+	end = cell
+	if end <= start {
+		end = start + 1
+	}
+	return start, end
+}
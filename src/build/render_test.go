@@ -0,0 +1,58 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package build
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUnderlineSpan(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		column     int
+		end_column int
+		start      int
+		end        int
+	}{
+		{"token", "abc", 2, 3, 1, 2},
+		{"whole line", "abc", 1, 4, 0, 3},
+		{"empty span", "abc", 1, 1, 0, 1},
+		{"tab before", "\tx", 5, 6, 4, 5},
+		{"tab inside", "a\tb", 1, 7, 0, 6},
+		{"multibyte before", "éx", 3, 4, 1, 2},
+		{"multibyte token", "éx", 1, 3, 0, 1},
+		{"column past end", "ab", 5, 7, 4, 5},
+		{"end past end", "ab", 1, 10, 0, 2},
+		{"rest of line", "a\tb", 2, math.MaxInt, 1, 6},
+		{"empty line", "", 1, 2, 0, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start, end := underline_span(test.line, test.column, test.end_column)
+			if start != test.start || end != test.end {
+				t.Errorf("underline_span(%q, %d, %d) = %d, %d, want %d, %d",
+					test.line, test.column, test.end_column, start, end, test.start, test.end)
+			}
+		})
+	}
+}
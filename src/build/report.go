@@ -0,0 +1,257 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package build
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/DeRuneLabs/jane"
+)
+
+const (
+	REPORT_TEXT  = "text"
+	REPORT_SHORT = "short"
+	REPORT_JSON  = "json"
+	REPORT_SARIF = "sarif"
+)
+
+type json_log struct {
+	Severity  string      `json:"severity"`
+	Key       string      `json:"key,omitempty"`
+	Path      string      `json:"path,omitempty"`
+	Row       int         `json:"row,omitempty"`
+	Column    int         `json:"column,omitempty"`
+	EndRow    int         `json:"end_row,omitempty"`
+	EndColumn int         `json:"end_column,omitempty"`
+	Args      []string    `json:"args,omitempty"`
+	Text      string      `json:"text"`
+	Notes     []json_note `json:"notes,omitempty"`
+}
+
+// Fields are in order of Note, so note is convertible.
+type json_note struct {
+	Row       int      `json:"row,omitempty"`
+	Column    int      `json:"column,omitempty"`
+	EndColumn int      `json:"end_column,omitempty"`
+	Path      string   `json:"path,omitempty"`
+	Key       string   `json:"key,omitempty"`
+	Args      []string `json:"args,omitempty"`
+	Text      string   `json:"text"`
+}
+
+func to_json_log(l Log) json_log {
+	jl := json_log{
+		Severity: l.Severity(),
+		Key:      l.Key,
+		Args:     l.Args,
+		Text:     l.Text,
+	}
+	if l.Type != FLAT_ERR {
+		jl.Path = l.Path
+		jl.Row = l.Row
+		jl.Column = l.Column
+		jl.EndRow = l.EndRow
+		jl.EndColumn = l.EndColumn
+	}
+	for _, n := range l.Notes {
+		jl.Notes = append(jl.Notes, json_note(n))
+	}
+	return jl
+}
+
+// Returns logs as JSON array.
+func LogsToJSON(logs []Log) string {
+	jlogs := make([]json_log, len(logs))
+	for i, l := range logs {
+		jlogs[i] = to_json_log(l)
+	}
+	bytes, _ := json.MarshalIndent(jlogs, "", "  ")
+	return string(bytes)
+}
+
+// Returns logs of JSON array reported by LogsToJSON.
+func LogsFromJSON(data []byte) ([]Log, error) {
+	var jlogs []json_log
+	err := json.Unmarshal(data, &jlogs)
+	if err != nil {
+		return nil, err
+	}
+	logs := make([]Log, len(jlogs))
+	for i, jl := range jlogs {
+		l := Log{
+			Type:      ERR,
+			Row:       jl.Row,
+			Column:    jl.Column,
+			EndRow:    jl.EndRow,
+			EndColumn: jl.EndColumn,
+			Path:      jl.Path,
+			Key:       jl.Key,
+			Args:      jl.Args,
+			Text:      jl.Text,
+		}
+		if jl.Path == "" {
+			l.Type = FLAT_ERR
+		}
+		l.Level, _ = LevelOfSeverity(jl.Severity)
+		for _, n := range jl.Notes {
+			l.Notes = append(l.Notes, Note(n))
+		}
+		logs[i] = l
+	}
+	return logs, nil
+}
+
+type sarif_log struct {
+	Version string      `json:"version"`
+	Schema  string      `json:"$schema"`
+	Runs    []sarif_run `json:"runs"`
+}
+
+type sarif_run struct {
+	Tool    sarif_tool     `json:"tool"`
+	Results []sarif_result `json:"results"`
+}
+
+type sarif_tool struct {
+	Driver sarif_driver `json:"driver"`
+}
+
+type sarif_driver struct {
+	Name    string       `json:"name"`
+	Version string       `json:"version"`
+	Rules   []sarif_rule `json:"rules,omitempty"`
+}
+
+type sarif_rule struct {
+	Id               string        `json:"id"`
+	ShortDescription sarif_message `json:"shortDescription"`
+}
+
+type sarif_message struct {
+	Text string `json:"text"`
+}
+
+type sarif_result struct {
+	RuleId           string           `json:"ruleId,omitempty"`
+	Level            string           `json:"level"`
+	Message          sarif_message    `json:"message"`
+	Locations        []sarif_location `json:"locations,omitempty"`
+	RelatedLocations []sarif_location `json:"relatedLocations,omitempty"`
+}
+
+type sarif_location struct {
+	PhysicalLocation *sarif_physical_location `json:"physicalLocation,omitempty"`
+	Message          *sarif_message           `json:"message,omitempty"`
+}
+
+type sarif_physical_location struct {
+	ArtifactLocation sarif_artifact_location `json:"artifactLocation"`
+	Region           sarif_region            `json:"region"`
+}
+
+type sarif_artifact_location struct {
+	Uri string `json:"uri"`
+}
+
+type sarif_region struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+func sarif_uri(path string) string {
+	if rel, err := filepath.Rel(jane.WORKING_PATH, path); err == nil && filepath.IsLocal(rel) {
+		path = rel
+	}
+	return filepath.ToSlash(path)
+}
+
+func to_sarif_result(l Log) sarif_result {
+	r := sarif_result{
+		RuleId:  l.Key,
+		Level:   l.Severity(),
+		Message: sarif_message{Text: l.Text},
+	}
+	if l.Type != FLAT_ERR {
+		r.Locations = []sarif_location{{
+			PhysicalLocation: sarif_physical_location_of(l.Path, l.Row, l.Column, l.EndRow, l.EndColumn),
+		}}
+	}
+	// Notes without location are reported as related location
+	// with only message, to keep them with result.
+	for _, n := range l.Notes {
+		loc := sarif_location{Message: &sarif_message{Text: n.Text}}
+		if n.Path != "" {
+			loc.PhysicalLocation = sarif_physical_location_of(n.Path, n.Row, n.Column, n.Row, n.EndColumn)
+		}
+		r.RelatedLocations = append(r.RelatedLocations, loc)
+	}
+	return r
+}
+
+func sarif_physical_location_of(path string, row int, column int, end_row int, end int) *sarif_physical_location {
+	if end_row <= row {
+		end_row = row
+		if end <= column {
+			end = column + 1
+		}
+	}
+	return &sarif_physical_location{
+		ArtifactLocation: sarif_artifact_location{Uri: sarif_uri(path)},
+		Region: sarif_region{
+			StartLine:   row,
+			StartColumn: column,
+			EndLine:     end_row,
+			EndColumn:   end,
+		},
+	}
+}
+
+// Returns logs as SARIF 2.1.0 report.
+func LogsToSARIF(logs []Log) string {
+	run := sarif_run{
+		Tool: sarif_tool{Driver: sarif_driver{
+			Name:    "jane",
+			Version: jane.VERSION,
+		}},
+		Results: make([]sarif_result, len(logs)),
+	}
+	rules := map[string]bool{}
+	for i, l := range logs {
+		run.Results[i] = to_sarif_result(l)
+		if l.Key != "" && !rules[l.Key] {
+			rules[l.Key] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarif_rule{
+				Id:               l.Key,
+				ShortDescription: sarif_message{Text: Message(l.Key)},
+			})
+		}
+	}
+	report := sarif_log{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarif_run{run},
+	}
+	bytes, _ := json.MarshalIndent(report, "", "  ")
+	return string(bytes)
+}
@@ -0,0 +1,111 @@
+// copyright (c) 2024 arfy slowy - derunelabs
+//
+// permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "software"), to deal
+// in the software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the software, and to permit persons to whom the software is
+// furnished to do so, subject to the following conditions:
+//
+// the above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the software.
+//
+// the software is provided "as is", without warranty of any kind, express or
+// implied, including but not limited to the warranties of merchantability,
+// fitness for a particular purpose and noninfringement. in no event shall the
+// authors or copyright holders be liable for any claim, damages or other
+// liability, whether in an action of contract, tort or otherwise, arising from,
+// out of or in connection with the software or the use or other dealings in the
+// software.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/DeRuneLabs/jane"
+	"github.com/DeRuneLabs/jane/build"
+	"github.com/DeRuneLabs/jane/manifest"
+)
+
+// Applies settings of manifest.
+// Command-line options are parsed after and override them.
+func apply_manifest(m *manifest.Manifest) {
+	out_dir = filepath.Join(m.Root, out_dir)
+	lib_name = m.Name
+	if m.Out != "" {
+		out = m.Out
+	}
+	if m.Compiler != "" {
+		switch m.Compiler {
+		case compiler_clang:
+			compiler_path = compiler_path_clang
+		case compiler_gcc:
+			compiler_path = compiler_path_gcc
+		}
+		compiler = m.Compiler
+	}
+	if m.Profile != "" {
+		profile = m.Profile
+	}
+	target_os = m.TargetOs
+	target_arch = m.TargetArch
+	cxxflags = append(cxxflags, m.CxxFlags...)
+	ldflags = append(ldflags, m.LdFlags...)
+	include_dirs = append(include_dirs, m.IncludeDirs...)
+	libs = append(libs, m.Libs...)
+}
+
+// Loads manifest of project of working directory if exist.
+// Library roots and diagnostic levels of project are applied,
+// so every command resolves and reports packages as build does.
+// Returns nil if there is no manifest.
+func load_project() *manifest.Manifest {
+	path := manifest.Find(jane.WORKING_PATH)
+	if path == "" {
+		return nil
+	}
+	m, err := manifest.Load(path)
+	if err != nil {
+		exit_err(jane.EXIT_USAGE, err.Error())
+	}
+	roots, err := m.LibraryRoots()
+	if err != nil {
+		exit_err(jane.EXIT_SETUP, err.Error())
+	}
+	jane.LIBRARY_PATHS = append(jane.LIBRARY_PATHS, roots...)
+	apply_lints(m.LintAllow, "lint.allow", build.Allow)
+	apply_lints(m.LintWarn, "lint.warn", build.Warn)
+	apply_lints(m.LintDeny, "lint.deny", build.Deny)
+	return m
+}
+
+// Loads manifest of project if exist and parses options.
+// Returns path of package to compile.
+func parse_project_options(args []string) string {
+	m := load_project()
+	if m != nil {
+		apply_manifest(m)
+	}
+	path := parse_options(args)
+	if path != "" {
+		return path
+	}
+	if m == nil {
+		exit_err(jane.EXIT_USAGE, "missing compile path and "+manifest.FILE_NAME+" is not found")
+	}
+	return m.Entry
+}
+
+// Compiles project of manifest, or package of path if given.
+// With --watch, project is compiled again when sources change.
+func build_project() {
+	args, watching := take_watch_option(os.Args[2:])
+	path := parse_project_options(args)
+	if watching {
+		watch(cmd_build, args, path)
+	}
+	build_package(path)
+	exit(jane.EXIT_SUCCESS)
+}
@@ -0,0 +1,354 @@
+// copyright (c) 2024 arfy slowy - derunelabs
+//
+// permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "software"), to deal
+// in the software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the software, and to permit persons to whom the software is
+// furnished to do so, subject to the following conditions:
+//
+// the above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the software.
+//
+// the software is provided "as is", without warranty of any kind, express or
+// implied, including but not limited to the warranties of merchantability,
+// fitness for a particular purpose and noninfringement. in no event shall the
+// authors or copyright holders be liable for any claim, damages or other
+// liability, whether in an action of contract, tort or otherwise, arising from,
+// out of or in connection with the software or the use or other dealings in the
+// software.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/DeRuneLabs/jane"
+	"github.com/DeRuneLabs/jane/build"
+	"github.com/DeRuneLabs/jane/command/jane/gen"
+	"github.com/DeRuneLabs/jane/parser"
+)
+
+// Build cache is content-addressed and stored under cache_dir:
+//
+//	manifest/<key>.json  sources consumed by last build of entry package,
+//	                     units generated by it and warnings reported by it
+//	code/<hash>.cpp      generated translation units
+//	obj/<hash>.o         object files of translation units
+//
+// Cached builds generate translation unit per Jane package, see gen.GenUnits.
+// Unit of package is made of sources of package and declarations of
+// packages it uses, for build options. Object files are keyed on unit,
+// C++ headers included by unit, backend compiler version and compile
+// flags. So objects of unchanged std and dependency packages are reused
+// across builds, even by builds of other entry packages, and editing
+// entry package compiles only its unit.
+//
+// Generated units are reused without parsing while all recorded
+// sources are unchanged.
+
+var (
+	use_cache = true
+	cache_dir = ""
+)
+
+// Sources consumed by the current build.
+var cache_deps []cache_dep
+
+type cache_dep struct {
+	Path  string `json:"path"`
+	IsDir bool   `json:"is_dir"`
+	Hash  string `json:"hash"`
+}
+
+// Generated unit recorded by manifest.
+type cache_unit struct {
+	Name    string   `json:"name,omitempty"`
+	Code    string   `json:"code"`
+	Headers []string `json:"headers,omitempty"`
+}
+
+type cache_manifest struct {
+	Deps  []cache_dep  `json:"deps"`
+	Units []cache_unit `json:"units"`
+	// Warnings and notes of build, printed again on hit.
+	Logs json.RawMessage `json:"logs,omitempty"`
+}
+
+func parse_cache_dir_option(args []string, i *int) {
+	value := get_option_value(args, i)
+	if value == "" {
+		exit_err(jane.EXIT_USAGE, "missing option value: --cache-dir")
+	}
+	cache_dir = value
+}
+
+// Returns cache directory, empty if not available.
+func get_cache_dir() string {
+	if cache_dir != "" {
+		return cache_dir
+	}
+	if dir := os.Getenv("JANE_CACHE"); dir != "" {
+		cache_dir = dir
+		return cache_dir
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	cache_dir = filepath.Join(dir, "jane")
+	return cache_dir
+}
+
+func hash_strings(strs ...string) string {
+	h := sha256.New()
+	for _, s := range strs {
+		_, _ = h.Write([]byte(s))
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func hash_file(path string) (string, bool) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(bytes)
+	return hex.EncodeToString(sum[:]), true
+}
+
+// Returns hash of jane source files of package directory
+// which are useable for current target.
+func hash_package(dir string) (string, bool) {
+	dirents, err := os.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+	var hashes []string
+	for _, dirent := range dirents {
+		name := dirent.Name()
+		if dirent.IsDir() ||
+			!strings.HasSuffix(name, jane.EXT) ||
+			!build.IsPassFileAnnotation(name) {
+			continue
+		}
+		hash, ok := hash_file(filepath.Join(dir, name))
+		if !ok {
+			return "", false
+		}
+		hashes = append(hashes, name, hash)
+	}
+	return hash_strings(hashes...), true
+}
+
+func hash_dep(path string, is_dir bool) (string, bool) {
+	if is_dir {
+		return hash_package(path)
+	}
+	return hash_file(path)
+}
+
+// Returns hash of C++ headers.
+func hash_headers(headers []string) string {
+	hashes := make([]string, 0, len(headers)*2)
+	for _, h := range headers {
+		hash, _ := hash_file(h)
+		hashes = append(hashes, h, hash)
+	}
+	return hash_strings(hashes...)
+}
+
+// Returns C++ headers of deps.
+func dep_headers(deps []cache_dep) []string {
+	var headers []string
+	for _, d := range deps {
+		if !d.IsDir {
+			headers = append(headers, d.Path)
+		}
+	}
+	return headers
+}
+
+// Returns hash of runtime API headers.
+func hash_api() string {
+	dir := filepath.Dir(jane_header)
+	var hashes []string
+	_ = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		hash, _ := hash_file(path)
+		hashes = append(hashes, path, hash)
+		return nil
+	})
+	return hash_strings(hashes...)
+}
+
+func get_manifest_path(path string) string {
+	abs, _ := filepath.Abs(path)
+	parts := append([]string{
+		jane.VERSION, build.OS, build.ARCH, jane.WORKING_PATH, abs,
+		strconv.FormatBool(gen.LineDirectives),
+		// Cached warnings are localized.
+		build.Locale(),
+	}, jane.LIBRARY_PATHS...)
+	// Diagnostic levels decide whether package compiles.
+	key := hash_strings(append(parts, build.LevelConfig()...)...)
+	return filepath.Join(get_cache_dir(), "manifest", key+".json")
+}
+
+func write_cache_file(path string, bytes []byte) {
+	// Write to temporary file first, so concurrent builds never
+	// read partially written entries.
+	dir := filepath.Dir(path)
+	if os.MkdirAll(dir, 0o777) != nil {
+		return
+	}
+	f, err := os.CreateTemp(dir, "tmp-")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(bytes)
+	_ = f.Close()
+	if err != nil || os.Rename(f.Name(), path) != nil {
+		_ = os.Remove(f.Name())
+	}
+}
+
+// Returns cached units and logs of package if all recorded
+// sources are unchanged.
+func load_cached_units(path string) ([]gen.Unit, []build.Log, bool) {
+	if !use_cache || get_cache_dir() == "" {
+		return nil, nil, false
+	}
+	bytes, err := os.ReadFile(get_manifest_path(path))
+	if err != nil {
+		return nil, nil, false
+	}
+	var manifest cache_manifest
+	if json.Unmarshal(bytes, &manifest) != nil {
+		return nil, nil, false
+	}
+	for _, d := range manifest.Deps {
+		hash, ok := hash_dep(d.Path, d.IsDir)
+		if !ok || hash != d.Hash {
+			return nil, nil, false
+		}
+	}
+	var logs []build.Log
+	if len(manifest.Logs) > 0 {
+		logs, err = build.LogsFromJSON(manifest.Logs)
+		if err != nil {
+			return nil, nil, false
+		}
+	}
+	units := make([]gen.Unit, len(manifest.Units))
+	for i, u := range manifest.Units {
+		bytes, err = os.ReadFile(filepath.Join(get_cache_dir(), "code", u.Code+".cpp"))
+		if err != nil {
+			return nil, nil, false
+		}
+		units[i] = gen.Unit{Name: u.Name, Code: string(bytes), Headers: u.Headers}
+	}
+	cache_deps = manifest.Deps
+	return units, logs, true
+}
+
+// Returns sources consumed by parsed package.
+func get_deps(path string, p *parser.Parser) (deps []cache_dep) {
+	push := func(path string, is_dir bool) {
+		hash, _ := hash_dep(path, is_dir)
+		deps = append(deps, cache_dep{Path: path, IsDir: is_dir, Hash: hash})
+	}
+	abs, _ := filepath.Abs(path)
+	push(abs, true)
+	for _, u := range *p.Used {
+		if u.Cpp && build.IsStdHeaderPath(u.Path) {
+			continue
+		}
+		push(u.Path, !u.Cpp)
+	}
+	return deps
+}
+
+// Stores generated units of package with consumed sources.
+func store_cached_units(path string, p *parser.Parser, units []gen.Unit) {
+	if !use_cache || get_cache_dir() == "" {
+		return
+	}
+	cache_deps = get_deps(path, p)
+	manifest := cache_manifest{
+		Deps:  cache_deps,
+		Units: make([]cache_unit, len(units)),
+	}
+	for i, u := range units {
+		code := hash_strings(u.Code)
+		write_cache_file(filepath.Join(get_cache_dir(), "code", code+".cpp"), []byte(u.Code))
+		manifest.Units[i] = cache_unit{Name: u.Name, Code: code, Headers: u.Headers}
+	}
+	if len(p.Errors) > 0 {
+		manifest.Logs = json.RawMessage(build.LogsToJSON(p.Errors))
+	}
+	bytes, err := json.Marshal(manifest)
+	if err != nil {
+		return
+	}
+	write_cache_file(get_manifest_path(path), bytes)
+}
+
+// Returns version output of backend compiler.
+func get_compiler_version() (string, bool) {
+	bytes, err := exec.Command(compiler_path, "--version").Output()
+	if err != nil {
+		return "", false
+	}
+	return string(bytes), true
+}
+
+// Compiles source into object file and returns path of it.
+// Object file is taken from cache if available,
+// headers are C++ headers included by source.
+func compile_object(source_path string, headers []string) string {
+	flags := gen_compile_flags()
+	if !use_cache || print_command || get_cache_dir() == "" {
+		return compile_object_direct(source_path, flags)
+	}
+	version, ok := get_compiler_version()
+	if !ok {
+		return compile_object_direct(source_path, flags)
+	}
+	code_hash, _ := hash_file(source_path)
+	key := hash_strings(append([]string{
+		compiler_path, version, code_hash, hash_headers(headers), hash_api(),
+	}, flags...)...)
+	obj := filepath.Join(get_cache_dir(), "obj", key+".o")
+
+	if _, err := os.Stat(obj); err != nil {
+		err = os.MkdirAll(filepath.Dir(obj), 0o777)
+		if err != nil {
+			exit_err(jane.EXIT_SETUP, err.Error())
+		}
+		tmp := obj + "." + strconv.Itoa(os.Getpid()) + ".tmp"
+		run_backend(compiler_path, append(flags, "-c", "-o", tmp, source_path))
+		err = os.Rename(tmp, obj)
+		if err != nil {
+			exit_err(jane.EXIT_SETUP, err.Error())
+		}
+	}
+	return obj
+}
+
+// Compiles source into object file next to it, without cache.
+func compile_object_direct(source_path string, flags []string) string {
+	obj := strings.TrimSuffix(source_path, filepath.Ext(source_path)) + ".o"
+	run_backend(compiler_path, append(flags, "-c", "-o", obj, source_path))
+	return obj
+}
@@ -0,0 +1,97 @@
+// copyright (c) 2024 arfy slowy - derunelabs
+//
+// permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "software"), to deal
+// in the software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the software, and to permit persons to whom the software is
+// furnished to do so, subject to the following conditions:
+//
+// the above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the software.
+//
+// the software is provided "as is", without warranty of any kind, express or
+// implied, including but not limited to the warranties of merchantability,
+// fitness for a particular purpose and noninfringement. in no event shall the
+// authors or copyright holders be liable for any claim, damages or other
+// liability, whether in an action of contract, tort or otherwise, arising from,
+// out of or in connection with the software or the use or other dealings in the
+// software.
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+
+	"github.com/DeRuneLabs/jane"
+	"github.com/DeRuneLabs/jane/build"
+)
+
+// Checks packages and reports diagnostics, without code generation.
+//
+// Parser is not safe for concurrent use, so multiple packages are
+// checked concurrently by worker processes of this command.
+func check() {
+	_ = load_project()
+	paths, options := parse_args(os.Args[2:])
+	if len(paths) == 0 {
+		exit_err(jane.EXIT_USAGE, "missing check path")
+	}
+	set()
+	var logs []build.Log
+	if len(paths) == 1 {
+		logs = compile(paths[0]).Errors
+	} else {
+		logs = check_concurrent(paths, options)
+	}
+	if print_log_list(logs) {
+		exit(logs_exit_code(logs))
+	}
+	exit(jane.EXIT_SUCCESS)
+}
+
+func check_concurrent(paths []string, options []string) []build.Log {
+	exe, err := os.Executable()
+	if err != nil {
+		exit_err(jane.EXIT_SETUP, err.Error())
+	}
+	results := make([][]build.Log, len(paths))
+	sem := make(chan struct{}, runtime.NumCPU())
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, path string) {
+			defer wg.Done()
+			results[i] = check_worker(exe, path, options)
+			<-sem
+		}(i, path)
+	}
+	wg.Wait()
+	var logs []build.Log
+	for _, result := range results {
+		logs = append(logs, result...)
+	}
+	return logs
+}
+
+// Checks package in worker process and returns reported logs.
+func check_worker(exe string, path string, options []string) []build.Log {
+	args := []string{cmd_check}
+	args = append(args, options...)
+	args = append(args, "--diagnostics="+build.REPORT_JSON, path)
+	command := exec.Command(exe, args...)
+	command.Stderr = os.Stderr
+	output, err := command.Output()
+	logs, json_err := build.LogsFromJSON(output)
+	if json_err != nil {
+		if err == nil {
+			err = json_err
+		}
+		return []build.Log{{Type: build.FLAT_ERR, Text: path + ": " + err.Error()}}
+	}
+	return logs
+}
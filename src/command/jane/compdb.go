@@ -0,0 +1,76 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/DeRuneLabs/jane"
+)
+
+const compile_commands_name = "compile_commands.json"
+
+// Writes compile_commands.json of generated units if enabled.
+var compile_commands = false
+
+// Entry of compilation database.
+type compile_command struct {
+	Directory string   `json:"directory"`
+	File      string   `json:"file"`
+	Arguments []string `json:"arguments"`
+	Output    string   `json:"output,omitempty"`
+}
+
+// Returns backend command of generated unit and its output.
+// Command has same compiler and flags as the one run by do_spell,
+// object files of cached builds are named by content in cache,
+// so object file next to unit is given as output instead.
+func gen_unit_cmd(source_path string) (c string, args []string, output string) {
+	if mode == mode_compile && buildmode == buildmode_exe && (!use_cache || print_command) {
+		c, args = gen_compile_cmd(source_path)
+		return c, args, out
+	}
+	output = strings.TrimSuffix(source_path, filepath.Ext(source_path)) + ".o"
+	args = append(gen_compile_flags(), "-c", "-o", output, source_path)
+	return compiler_path, args, output
+}
+
+// Writes compilation database of generated units into output directory,
+// so tools such as clangd understand generated code.
+func write_compile_commands(dir string, units []string) {
+	commands := make([]compile_command, len(units))
+	for i, unit := range units {
+		c, args, output := gen_unit_cmd(unit)
+		commands[i] = compile_command{
+			Directory: jane.WORKING_PATH,
+			File:      unit,
+			Arguments: append([]string{c}, args...),
+			Output:    output,
+		}
+	}
+	bytes, err := json.MarshalIndent(commands, "", "  ")
+	if err != nil {
+		exit_err(jane.EXIT_SETUP, err.Error())
+	}
+	write_output(filepath.Join(dir, compile_commands_name), string(bytes)+"\n")
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DeRuneLabs/jane"
+	"github.com/DeRuneLabs/jane/build"
+	"github.com/DeRuneLabs/jane/doc"
+	"github.com/DeRuneLabs/jane/lexer"
+	"github.com/DeRuneLabs/jane/manifest"
+	"github.com/DeRuneLabs/jane/parser"
+)
+
+const (
+	doc_format_html     = "html"
+	doc_format_markdown = "markdown"
+	doc_format_json     = "json"
+)
+
+var (
+	doc_format = doc_format_html
+	doc_out    = "doc"
+)
+
+// Generates documentation of package and its used packages.
+func doc_command() {
+	path := parse_doc_options(os.Args[2:], load_project())
+	inf, err := os.Stat(jane.STDLIB_PATH)
+	if err != nil || !inf.IsDir() {
+		print_log_list([]build.Log{build.FlatErr("stdlib_not_exist")})
+		exit(jane.EXIT_SETUP)
+	}
+	p, err_msg := parser.ParsePackage(path, true)
+	if err_msg != "" {
+		exit_err(jane.EXIT_SETUP, err_msg)
+	}
+	if build.HasErrors(p.Errors) {
+		logs := append(p.Errors, build.FlatErr("doc_couldnt_generated", path))
+		print_log_list(logs)
+		exit(logs_exit_code(logs))
+	}
+	set := new(doc.Set)
+	set.Packages = append(set.Packages, doc.New(doc_package_name(path), path, p.Defines, p.PackageUses()))
+	for _, u := range *p.Used {
+		if !u.Cpp {
+			set.Packages = append(set.Packages, doc.New(u.LinkString, u.Path, u.Defines, u.PackageUses))
+		}
+	}
+	set.Link()
+	write_docs(set)
+	exit(jane.EXIT_SUCCESS)
+}
+
+// Parses options of doc command and returns package path.
+// Entry package of project manifest is used if path is not given.
+func parse_doc_options(args []string, m *manifest.Manifest) string {
+	var paths []string
+	for i := 0; i < len(args); i++ {
+		arg, content := get_option(args, &i)
+		if content != "" {
+			paths = append(paths, content)
+		}
+		switch arg {
+		case "":
+		case "--format":
+			doc_format = get_option_value(args, &i)
+			switch doc_format {
+			case "":
+				exit_err(jane.EXIT_USAGE, "missing option value: --format")
+			case doc_format_html, doc_format_markdown, doc_format_json:
+			default:
+				exit_err(jane.EXIT_USAGE, "invalid documentation format: "+doc_format)
+			}
+		case "-o", "--out":
+			doc_out = get_option_value(args, &i)
+			if doc_out == "" {
+				exit_err(jane.EXIT_USAGE, "missing option value: -o --out")
+			}
+		default:
+			exit_err(jane.EXIT_USAGE, "undefined option: "+arg)
+		}
+		if inline_value != nil {
+			exit_err(jane.EXIT_USAGE, "option does not take value: "+arg)
+		}
+	}
+	switch len(paths) {
+	case 0:
+	case 1:
+		return paths[0]
+	default:
+		exit_err(jane.EXIT_USAGE, "too many documentation paths: "+strings.Join(paths[1:], " "))
+	}
+	if m != nil {
+		lib_name = m.Name
+		return m.Entry
+	}
+	exit_err(jane.EXIT_USAGE, "missing documentation path and "+manifest.FILE_NAME+" is not found")
+	return ""
+}
+
+// Returns name of documented package.
+// Packages of standard library are named as used.
+func doc_package_name(path string) string {
+	abs, _ := filepath.Abs(path)
+	rel, err := filepath.Rel(jane.STDLIB_PATH, abs)
+	if err == nil && !strings.HasPrefix(rel, "..") {
+		name := jane.STDLIB
+		if rel != "." {
+			name += lexer.KND_DBLCOLON + strings.ReplaceAll(filepath.ToSlash(rel), "/", lexer.KND_DBLCOLON)
+		}
+		return name
+	}
+	set_lib_name(path)
+	return lib_name
+}
+
+func write_docs(set *doc.Set) {
+	switch doc_format {
+	case doc_format_json:
+		write_output(filepath.Join(doc_out, doc.INDEX_NAME+doc.EXT_JSON), set.JSON())
+	case doc_format_markdown:
+		write_output(filepath.Join(doc_out, doc.INDEX_NAME+doc.EXT_MARKDOWN), set.IndexMarkdown())
+		for _, p := range set.Packages {
+			write_output(filepath.Join(doc_out, p.FileName()+doc.EXT_MARKDOWN), p.Markdown())
+		}
+	default:
+		write_output(filepath.Join(doc_out, doc.INDEX_NAME+doc.EXT_HTML), set.IndexHTML())
+		for _, p := range set.Packages {
+			write_output(filepath.Join(doc_out, p.FileName()+doc.EXT_HTML), p.HTML())
+		}
+	}
+}
@@ -0,0 +1,455 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/DeRuneLabs/jane"
+	"github.com/DeRuneLabs/jane/build"
+	"github.com/DeRuneLabs/jane/lexer"
+	"github.com/DeRuneLabs/jane/parser"
+)
+
+const (
+	dump_format_text = "text"
+	dump_format_json = "json"
+)
+
+// Options of dump tool commands.
+type dump_options struct {
+	path     string
+	format   string
+	comments bool
+}
+
+// Parses options of dump tool commands.
+// Comments option is allowed only if comments is true.
+func parse_dump_options(args []string, comments bool) dump_options {
+	opts := dump_options{format: dump_format_text}
+	for i := 0; i < len(args); i++ {
+		arg, content := get_option(args, &i)
+		switch arg {
+		case "":
+			if opts.path != "" {
+				exit_err(jane.EXIT_USAGE, "too many paths: "+content)
+			}
+			opts.path = content
+			continue
+		case "--format":
+			opts.format = get_option_value(args, &i)
+			switch opts.format {
+			case dump_format_text, dump_format_json:
+			default:
+				exit_err(jane.EXIT_USAGE, "invalid format: "+opts.format)
+			}
+			continue
+		case "--comments":
+			if comments {
+				opts.comments = true
+				break
+			}
+			fallthrough
+		default:
+			exit_err(jane.EXIT_USAGE, "undefined option: "+arg)
+		}
+		if inline_value != nil {
+			exit_err(jane.EXIT_USAGE, "option does not take value: "+arg)
+		}
+	}
+	if opts.path == "" {
+		exit_err(jane.EXIT_USAGE, "missing source file path")
+	}
+	return opts
+}
+
+// Returns tokens of source file, exits if file is not readable.
+func lex_file(path string, comments bool) ([]lexer.Token, []build.Log) {
+	if _, err := os.Stat(path); err != nil {
+		exit_err(jane.EXIT_USAGE, err.Error())
+	}
+	l := lexer.New(lexer.NewFile(path))
+	l.KeepComments = comments
+	toks := l.Lex()
+	return toks, l.Logs
+}
+
+// Returns name of token identifier.
+func token_id_name(id uint8) string {
+	if int(id) < len(lexer.ID_NAMES) {
+		return lexer.ID_NAMES[id]
+	}
+	return strconv.Itoa(int(id))
+}
+
+// Returns kind of token in printable form.
+func token_kind_string(kind string) string {
+	if strings.ContainsAny(kind, "\n\r\t") {
+		return strconv.Quote(kind)
+	}
+	return kind
+}
+
+// Prints tokens of source file with identifier, kind and position.
+func tool_tokens(args []string) {
+	opts := parse_dump_options(args, true)
+	toks, logs := lex_file(opts.path, opts.comments)
+	switch opts.format {
+	case dump_format_json:
+		type json_token struct {
+			Row    int    `json:"row"`
+			Column int    `json:"column"`
+			Id     uint8  `json:"id"`
+			IdName string `json:"id_name"`
+			Kind   string `json:"kind"`
+		}
+		list := make([]json_token, len(toks))
+		for i, t := range toks {
+			list[i] = json_token{t.Row, t.Column, t.Id, token_id_name(t.Id), t.Kind}
+		}
+		print_json(list)
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, t := range toks {
+			fmt.Fprintf(w, "%d:%d\t%s(%d)\t%s\n",
+				t.Row, t.Column, token_id_name(t.Id), t.Id, token_kind_string(t.Kind))
+		}
+		_ = w.Flush()
+	}
+	if print_log_list(logs) {
+		exit(jane.EXIT_DIAG)
+	}
+}
+
+// Prints syntax tree of source file.
+// Tree is printed even if builder reports errors, it is useful
+// for debugging to see what builder produced before errors.
+func tool_ast(args []string) {
+	opts := parse_dump_options(args, false)
+	toks, logs := lex_file(opts.path, false)
+	if print_log_list(logs) {
+		exit(jane.EXIT_DIAG)
+	}
+	tree, logs := parser.BuildTree(toks)
+	d := new_dumper()
+	root := d.dump(reflect.ValueOf(tree))
+	switch opts.format {
+	case dump_format_json:
+		print_json(root)
+	default:
+		var sb strings.Builder
+		if root != nil {
+			root.write_text(&sb, 0)
+		}
+		print(sb.String())
+	}
+	if print_log_list(logs) {
+		exit(jane.EXIT_DIAG)
+	}
+}
+
+func print_json(v any) {
+	data, err := json_bytes(v)
+	if err != nil {
+		exit_err(jane.EXIT_SETUP, err.Error())
+	}
+	var out bytes.Buffer
+	_ = json.Indent(&out, data, "", "  ")
+	fmt.Println(out.String())
+}
+
+// Returns JSON encoding of value without HTML escaping,
+// source code is full of angle brackets and ampersands.
+func json_bytes(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// Dumped value of syntax tree.
+// Fields of structures keep declaration order.
+type dump_value struct {
+	typ    string
+	scalar any
+	fields []dump_field
+	items  []*dump_value
+	list   bool
+}
+
+type dump_field struct {
+	name  string
+	value *dump_value
+}
+
+// Maximum depth of dump, deeper values are not dumped.
+const DUMP_MAX_DEPTH = 64
+
+var token_type = reflect.TypeOf(lexer.Token{})
+
+// Dumps values by reflection.
+// Zero values are omitted to keep dump readable.
+type dumper struct {
+	visiting map[uintptr]bool
+	depth    int
+}
+
+func new_dumper() *dumper {
+	return &dumper{visiting: map[uintptr]bool{}}
+}
+
+// Returns dump of value, nil if value is omitted.
+func (d *dumper) dump(v reflect.Value) *dump_value {
+	if !v.IsValid() || d.depth > DUMP_MAX_DEPTH {
+		return nil
+	}
+	d.depth++
+	defer func() { d.depth-- }()
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		// Parent links of tree makes cycles.
+		ptr := v.Pointer()
+		if d.visiting[ptr] {
+			return &dump_value{typ: "<cycle>"}
+		}
+		d.visiting[ptr] = true
+		defer delete(d.visiting, ptr)
+		return d.dump(v.Elem())
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		dv := d.dump(v.Elem())
+		if dv != nil && dv.typ == "" {
+			dv.typ = type_name(v.Elem().Type())
+		}
+		return dv
+	case reflect.Struct:
+		return d.dump_struct(v)
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			return nil
+		}
+		dv := &dump_value{list: true}
+		for i := 0; i < v.Len(); i++ {
+			item := d.dump(v.Index(i))
+			if item == nil {
+				item = &dump_value{scalar: nil}
+			}
+			dv.items = append(dv.items, item)
+		}
+		return dv
+	case reflect.Map:
+		if v.Len() == 0 {
+			return nil
+		}
+		dv := &dump_value{typ: type_name(v.Type())}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		for _, key := range keys {
+			if value := d.dump(v.MapIndex(key)); value != nil {
+				dv.fields = append(dv.fields, dump_field{fmt.Sprint(key), value})
+			}
+		}
+		return dv
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return nil
+	case reflect.String:
+		return &dump_value{scalar: v.String()}
+	case reflect.Bool:
+		return &dump_value{scalar: v.Bool()}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &dump_value{scalar: v.Int()}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &dump_value{scalar: v.Uint()}
+	case reflect.Float32, reflect.Float64:
+		return &dump_value{scalar: v.Float()}
+	}
+	return &dump_value{scalar: fmt.Sprint(v)}
+}
+
+func (d *dumper) dump_struct(v reflect.Value) *dump_value {
+	t := v.Type()
+	if t == token_type {
+		tok := v.Interface().(lexer.Token)
+		if tok.Id == lexer.ID_NA && tok.Kind == "" {
+			return nil
+		}
+		return &dump_value{
+			typ: "Token",
+			fields: []dump_field{
+				{"kind", &dump_value{scalar: tok.Kind}},
+				{"id", &dump_value{scalar: token_id_name(tok.Id)}},
+				{"row", &dump_value{scalar: tok.Row}},
+				{"column", &dump_value{scalar: tok.Column}},
+			},
+		}
+	}
+	dv := &dump_value{typ: type_name(t)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		if fv.IsZero() {
+			continue
+		}
+		if value := d.dump(fv); value != nil {
+			dv.fields = append(dv.fields, dump_field{f.Name, value})
+		}
+	}
+	return dv
+}
+
+// Returns type name without package qualifier.
+func type_name(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Name() != "" {
+		return t.Name()
+	}
+	return t.String()
+}
+
+func (dv *dump_value) MarshalJSON() ([]byte, error) {
+	switch {
+	case dv.list:
+		return json_bytes(dv.items)
+	case dv.fields == nil && dv.typ == "":
+		return json_bytes(dv.scalar)
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	if dv.typ != "" {
+		data, err := json_bytes(dv.typ)
+		if err != nil {
+			return nil, err
+		}
+		sb.WriteString(`"type":`)
+		sb.Write(data)
+	}
+	if dv.fields == nil && dv.scalar != nil {
+		// Named scalar types such as enumerations.
+		data, err := json_bytes(dv.scalar)
+		if err != nil {
+			return nil, err
+		}
+		sb.WriteString(`,"value":`)
+		sb.Write(data)
+	}
+	for i, f := range dv.fields {
+		if i > 0 || dv.typ != "" {
+			sb.WriteByte(',')
+		}
+		data, err := json_bytes(f.value)
+		if err != nil {
+			return nil, err
+		}
+		sb.WriteString(strconv.Quote(f.name))
+		sb.WriteByte(':')
+		sb.Write(data)
+	}
+	sb.WriteByte('}')
+	return []byte(sb.String()), nil
+}
+
+// Returns scalar of value in text form.
+func scalar_text(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return strconv.Quote(t)
+	}
+	return fmt.Sprint(v)
+}
+
+// Returns token in compact text form.
+func (dv *dump_value) token_text() string {
+	var s [4]string
+	for i, f := range dv.fields {
+		s[i] = fmt.Sprint(f.value.scalar)
+	}
+	return fmt.Sprintf("%s %s %s:%s", s[1], strconv.Quote(s[0]), s[2], s[3])
+}
+
+// Writes value in indented text form.
+// Value header is written by caller, only nested lines are written.
+func (dv *dump_value) write_text(sb *strings.Builder, indent int) {
+	prefix := strings.Repeat("  ", indent)
+	if dv.list {
+		for _, item := range dv.items {
+			sb.WriteString(prefix)
+			sb.WriteString("- ")
+			sb.WriteString(item.header())
+			sb.WriteByte('\n')
+			item.write_text(sb, indent+1)
+		}
+		return
+	}
+	if dv.typ == "Token" {
+		return
+	}
+	for _, f := range dv.fields {
+		sb.WriteString(prefix)
+		sb.WriteString(f.name)
+		sb.WriteByte(':')
+		if header := f.value.header(); header != "" {
+			sb.WriteByte(' ')
+			sb.WriteString(header)
+		}
+		sb.WriteByte('\n')
+		f.value.write_text(sb, indent+1)
+	}
+}
+
+// Returns text of value that is written in line of value.
+func (dv *dump_value) header() string {
+	switch {
+	case dv.list:
+		return ""
+	case dv.typ == "Token":
+		return dv.token_text()
+	case dv.fields == nil && dv.typ == "":
+		return scalar_text(dv.scalar)
+	case dv.fields == nil && dv.scalar != nil:
+		return dv.typ + "(" + scalar_text(dv.scalar) + ")"
+	}
+	return dv.typ
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/DeRuneLabs/jane"
+	"github.com/DeRuneLabs/jane/build"
+	"github.com/DeRuneLabs/jane/format"
+)
+
+// Formats jane source files of paths in canonical layout.
+//
+// Files are rewritten in place by default.
+// With --check, unformatted files are listed and command fails.
+// With --diff, changes are printed instead of rewriting files.
+func fmt_command() {
+	args := os.Args[2:]
+	check_only := false
+	print_diff := false
+	var paths []string
+	for i := 0; i < len(args); i++ {
+		arg, content := get_option(args, &i)
+		if content != "" {
+			paths = append(paths, content)
+		}
+		switch arg {
+		case "":
+		case "--check":
+			check_only = true
+		case "--diff":
+			print_diff = true
+		default:
+			exit_err(jane.EXIT_USAGE, "undefined option: "+arg)
+		}
+		if inline_value != nil {
+			exit_err(jane.EXIT_USAGE, "option does not take value: "+arg)
+		}
+	}
+	if len(paths) == 0 {
+		paths = append(paths, ".")
+	}
+	files, err := find_source_files(paths)
+	if err != nil {
+		exit_err(jane.EXIT_USAGE, err.Error())
+	}
+	var logs []build.Log
+	unformatted := false
+	for _, path := range files {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			exit_err(jane.EXIT_SETUP, err.Error())
+		}
+		out, file_logs := format.Format(path, src)
+		if len(file_logs) > 0 {
+			logs = append(logs, file_logs...)
+			continue
+		}
+		if string(out) == string(src) {
+			continue
+		}
+		unformatted = true
+		switch {
+		case print_diff:
+			print(format.Diff(path, src, out))
+		case check_only:
+			println(path)
+		default:
+			write_formatted(path, out)
+		}
+	}
+	if print_log_list(logs) {
+		exit(jane.EXIT_DIAG)
+	}
+	if check_only && unformatted {
+		exit(jane.EXIT_DIAG)
+	}
+}
+
+// Returns jane source files of paths.
+// Directories are searched recursively.
+func find_source_files(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && filepath.Ext(path) == jane.EXT {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func write_formatted(path string, content []byte) {
+	info, err := os.Stat(path)
+	if err != nil {
+		exit_err(jane.EXIT_SETUP, err.Error())
+	}
+	err = os.WriteFile(path, content, info.Mode().Perm())
+	if err != nil {
+		exit_err(jane.EXIT_SETUP, err.Error())
+	}
+}
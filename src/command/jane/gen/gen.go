@@ -21,6 +21,8 @@
 package gen
 
 import (
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync/atomic"
 
@@ -38,6 +40,59 @@ var (
 
 const init_caller = "__jane_call_package_initializers"
 
+// Emits #line directives that maps generated code to Jane sources.
+var LineDirectives = false
+
+// Marker of line directive that maps following code back to generated
+// file. Markers are replaced by RestoreLines, because lines of output
+// are not known until output is complete.
+const line_restore_marker = "#line __JANE_GENERATED__"
+
+// Returns #line directive of token followed by indentation.
+// Returns empty string if directives are disabled or token has no file.
+func gen_line(tok lexer.Token) string {
+	if !LineDirectives || tok.File == nil || tok.Row < 1 {
+		return ""
+	}
+	path := tok.File.Path()
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	var cpp strings.Builder
+	cpp.WriteString("#line ")
+	cpp.WriteString(strconv.Itoa(tok.Row))
+	cpp.WriteByte(' ')
+	cpp.WriteString(strconv.Quote(filepath.ToSlash(path)))
+	cpp.WriteByte('\n')
+	cpp.WriteString(indent_string())
+	return cpp.String()
+}
+
+// Returns directive that restores line mapping to generated file.
+func gen_line_restore() string {
+	if !LineDirectives {
+		return ""
+	}
+	return "\n" + line_restore_marker
+}
+
+// Replaces line restore markers of code with #line directives
+// of generated file at path.
+func RestoreLines(code string, path string) string {
+	if !LineDirectives {
+		return code
+	}
+	lines := strings.Split(code, "\n")
+	quoted := strconv.Quote(filepath.ToSlash(path))
+	for i, line := range lines {
+		if line == line_restore_marker {
+			// Line numbers start at 1 and directive applies to next line.
+			lines[i] = "#line " + strconv.Itoa(i+2) + " " + quoted
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 func repeat(sub string, n uint32) string {
 	if n == 0 {
 		return ""
@@ -234,6 +289,7 @@ func gen_parse_block(b *ast.Block) string {
 		}
 		cpp.WriteByte('\n')
 		cpp.WriteString(indent_string())
+		cpp.WriteString(gen_line(s.Token))
 		cpp.WriteString(gen_st(&s))
 	}
 	cpp.WriteByte('\n')
@@ -513,7 +569,7 @@ func gen_type_alias(t *ast.TypeAlias) string {
 	if t.Generic {
 		cpp.WriteString(build.AsId(t.Id))
 	} else {
-		cpp.WriteString(build.OutId(t.Id, t.Token.File.Addr()))
+		cpp.WriteString(build.OutId(t.Id, t.Token.File.Id()))
 	}
 	cpp.WriteByte(';')
 	return cpp.String()
@@ -718,6 +774,9 @@ func gen_struct_ostream(s *ast.Struct) string {
 	cpp.WriteString(indent_string())
 	if l, _ := cpp.WriteString(genericsDef); l > 0 {
 		cpp.WriteString(indent_string())
+	} else if units {
+		// Declared by every unit that declares struct.
+		cpp.WriteString("inline ")
 	}
 	cpp.WriteString("std::ostream &operator<<(std::ostream &_Stream, const ")
 	cpp.WriteString(s.OutId())
@@ -936,12 +995,16 @@ func gen_struct_plain_prototype(s *ast.Struct) string {
 	return cpp.String()
 }
 
-func gen_struct_fn_defs(s *ast.Struct) string {
+// Returns definitions of used methods of struct,
+// inline methods if inline is true, others if not.
+func gen_struct_fn_defs(s *ast.Struct, inline bool) string {
 	var cpp strings.Builder
 	for _, f := range s.Defines.Fns {
-		if f.Used {
+		if f.Used && is_inline(f, s) == inline {
 			cpp.WriteString(indent_string())
+			cpp.WriteString(gen_line(f.Token))
 			cpp.WriteString(gen_fn_owner(f, s))
+			cpp.WriteString(gen_line_restore())
 			cpp.WriteString("\n\n")
 		}
 	}
@@ -950,7 +1013,7 @@ func gen_struct_fn_defs(s *ast.Struct) string {
 
 func gen_struct(s *ast.Struct) string {
 	var cpp strings.Builder
-	cpp.WriteString(gen_struct_fn_defs(s))
+	cpp.WriteString(gen_struct_fn_defs(s, true))
 	cpp.WriteString("\n\n")
 	cpp.WriteString(gen_struct_ostream(s))
 	return cpp.String()
@@ -970,7 +1033,7 @@ func gen_fn_decl_head(f *ast.Fn, owner *ast.Struct) string {
 		cpp.WriteByte('\n')
 		cpp.WriteString(indent_string())
 	}
-	if !f.IsEntryPoint {
+	if is_inline(f, owner) {
 		cpp.WriteString("inline ")
 	}
 	cpp.WriteString(f.RetType.String())
@@ -1171,7 +1234,9 @@ func _gen_fns(dm *ast.Defmap) string {
 	var cpp strings.Builder
 	for _, f := range dm.Fns {
 		if f.Used && f.Token.Id != lexer.ID_NA {
+			cpp.WriteString(gen_line(f.Token))
 			cpp.WriteString(gen_fn(f))
+			cpp.WriteString(gen_line_restore())
 			cpp.WriteString("\n\n")
 		}
 	}
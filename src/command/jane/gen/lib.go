@@ -0,0 +1,344 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package gen
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/DeRuneLabs/jane/ast"
+	"github.com/DeRuneLabs/jane/build"
+	"github.com/DeRuneLabs/jane/lexer"
+)
+
+// Libraries export public functions and structs of library package
+// with C types, so generated header has no dependency to runtime and
+// runtime is defined only by library. Exported structs are declared
+// as C structs by header and converted by wrappers of functions.
+
+// Reports whether function is public function of library.
+// Generic functions and methods are not public functions.
+func is_public_fn(f *ast.Fn) bool {
+	return f.Public && f.Token.Id != lexer.ID_NA &&
+		len(f.Generics) == 0 && f.Receiver == nil
+}
+
+// Returns struct of type if type is struct value.
+func struct_of(dt ast.Type) *ast.Struct {
+	dt.SetToOriginal()
+	if dt.MultiTyped || dt.CppLinked || dt.Modifiers() != "" {
+		return nil
+	}
+	s, _ := dt.Tag.(*ast.Struct)
+	if s != nil && s.Origin != nil {
+		s = s.Origin
+	}
+	return s
+}
+
+// Reports whether struct is public struct of library.
+func is_public_struct(tree *ast.Defmap, s *ast.Struct) bool {
+	if !s.Pub || s.Token.Id == lexer.ID_NA || s.CppLinked || len(s.Generics) > 0 {
+		return false
+	}
+	for _, ts := range tree.Structs {
+		if ts == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether struct is exported,
+// public struct of library with fields of C type.
+func is_exported_struct(tree *ast.Defmap, s *ast.Struct) bool {
+	if !is_public_struct(tree, s) {
+		return false
+	}
+	for _, field := range s.Fields {
+		if _, ok := c_type(tree, field.DataType); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns C type of type, reports false if type has no C type.
+// C type of exported struct is its C struct.
+func c_type(tree *ast.Defmap, dt ast.Type) (string, bool) {
+	if t, ok := dt.CType(); ok {
+		return t, true
+	}
+	s := struct_of(dt)
+	if s != nil && is_exported_struct(tree, s) {
+		return s.Id, true
+	}
+	return "", false
+}
+
+// Reports whether all types of function signature have C type.
+func has_c_signature(tree *ast.Defmap, f *ast.Fn) bool {
+	if _, ok := c_type(tree, f.RetType.DataType); !ok {
+		return false
+	}
+	for _, param := range f.Params {
+		if _, ok := c_type(tree, param.DataType); !ok || param.Variadic {
+			return false
+		}
+	}
+	return true
+}
+
+// Reports whether function is exported by library builds.
+func is_exported_fn(tree *ast.Defmap, f *ast.Fn) bool {
+	return is_public_fn(f) && has_c_signature(tree, f)
+}
+
+// Returns exported structs of tree, structs of fields first.
+func exported_structs(tree *ast.Defmap) []*ast.Struct {
+	var order []*ast.Struct
+	done := map[*ast.Struct]bool{}
+	var push func(s *ast.Struct)
+	push = func(s *ast.Struct) {
+		if done[s] {
+			return
+		}
+		done[s] = true
+		for _, field := range s.Fields {
+			if fs := struct_of(field.DataType); fs != nil {
+				push(fs)
+			}
+		}
+		order = append(order, s)
+	}
+	for _, s := range tree.Structs {
+		if is_exported_struct(tree, s) {
+			push(s)
+		}
+	}
+	return order
+}
+
+// Marks exported functions and structs of tree as used,
+// so library builds generate them without being called.
+func UseExports(tree *ast.Defmap) {
+	for _, f := range tree.Fns {
+		if is_exported_fn(tree, f) {
+			f.Used = true
+		}
+	}
+	for _, s := range exported_structs(tree) {
+		s.Used = true
+	}
+}
+
+func export_log(t lexer.Token, key string, args ...any) build.Log {
+	return build.Err(t.Row, t.Column, t.EndRow(), t.EndColumn(), t.File.Path(), key, args...)
+}
+
+// Returns logs of public defines of tree that are not exported.
+func CheckExports(tree *ast.Defmap) []build.Log {
+	var logs []build.Log
+	for _, f := range tree.Fns {
+		switch {
+		case !f.Public || f.Token.Id == lexer.ID_NA:
+		case len(f.Generics) > 0:
+			logs = append(logs, export_log(f.Token, "define_not_exported", f.Id))
+		case !has_c_signature(tree, f):
+			logs = append(logs, export_log(f.Token, "fn_not_exported", f.Id))
+		}
+	}
+	for _, s := range tree.Structs {
+		switch {
+		case !s.Pub || s.Token.Id == lexer.ID_NA:
+		case !is_public_struct(tree, s):
+			logs = append(logs, export_log(s.Token, "define_not_exported", s.Id))
+		case !is_exported_struct(tree, s):
+			logs = append(logs, export_log(s.Token, "struct_not_exported", s.Id))
+		}
+		if s.Token.Id == lexer.ID_NA {
+			continue
+		}
+		for _, f := range s.Defines.Fns {
+			if f.Public {
+				logs = append(logs, export_log(f.Token, "define_not_exported", s.Id+"."+f.Id))
+			}
+		}
+	}
+	for _, g := range tree.Globals {
+		if g.Public && g.Token.Id != lexer.ID_NA {
+			logs = append(logs, export_log(g.Token, "define_not_exported", g.Id))
+		}
+	}
+	for _, e := range tree.Enums {
+		if e.Pub && e.Token.Id != lexer.ID_NA {
+			logs = append(logs, export_log(e.Token, "define_not_exported", e.Id))
+		}
+	}
+	for _, t := range tree.Traits {
+		if t.Pub && t.Token.Id != lexer.ID_NA {
+			logs = append(logs, export_log(t.Token, "define_not_exported", t.Id))
+		}
+	}
+	for _, t := range tree.Types {
+		if t.Pub && t.Token.Id != lexer.ID_NA {
+			logs = append(logs, export_log(t.Token, "define_not_exported", t.Id))
+		}
+	}
+	return logs
+}
+
+// Returns C struct declaration of exported struct.
+func gen_c_struct(tree *ast.Defmap, s *ast.Struct) string {
+	var cpp strings.Builder
+	cpp.WriteString("struct ")
+	cpp.WriteString(s.Id)
+	cpp.WriteString(" {\n")
+	for _, field := range s.Fields {
+		t, _ := c_type(tree, field.DataType)
+		cpp.WriteString(indentation)
+		cpp.WriteString(t)
+		cpp.WriteByte(' ')
+		cpp.WriteString(field.Id)
+		cpp.WriteString(";\n")
+	}
+	cpp.WriteString("};\n")
+	return cpp.String()
+}
+
+// Returns converters between exported struct and its C struct.
+func gen_c_struct_converters(s *ast.Struct) string {
+	var to_c strings.Builder
+	var to_jane strings.Builder
+	outid := s.OutId()
+	to_c.WriteString("inline " + s.Id + " _to_c(const " + outid + " &_s) noexcept {\n")
+	to_c.WriteString(indentation + s.Id + " _c;\n")
+	to_jane.WriteString("inline " + outid + " _to_jane(const " + s.Id + " &_c) noexcept {\n")
+	to_jane.WriteString(indentation + outid + " _s;\n")
+	for _, field := range s.Fields {
+		c := "_c." + field.Id
+		jane := "_s." + field.OutId()
+		if struct_of(field.DataType) != nil {
+			to_c.WriteString(indentation + c + " = _to_c(" + jane + ");\n")
+			to_jane.WriteString(indentation + jane + " = _to_jane(" + c + ");\n")
+		} else {
+			to_c.WriteString(indentation + c + " = " + jane + ";\n")
+			to_jane.WriteString(indentation + jane + " = " + c + ";\n")
+		}
+	}
+	to_c.WriteString(indentation + "return _c;\n}\n")
+	to_jane.WriteString(indentation + "return _s;\n}\n")
+	return to_c.String() + to_jane.String()
+}
+
+func gen_export_params(tree *ast.Defmap, f *ast.Fn) (params string, args string) {
+	if len(f.Params) == 0 {
+		return "(void)", "()"
+	}
+	var p strings.Builder
+	var a strings.Builder
+	p.WriteByte('(')
+	a.WriteByte('(')
+	for i, param := range f.Params {
+		if i > 0 {
+			p.WriteString(", ")
+			a.WriteString(", ")
+		}
+		id := "_p" + strconv.Itoa(i)
+		t, _ := c_type(tree, param.DataType)
+		p.WriteString(t)
+		p.WriteByte(' ')
+		p.WriteString(id)
+		if struct_of(param.DataType) != nil {
+			a.WriteString("_to_jane(" + id + ")")
+		} else {
+			a.WriteString(id)
+		}
+	}
+	p.WriteByte(')')
+	a.WriteByte(')')
+	return p.String(), a.String()
+}
+
+func gen_export_fn_head(tree *ast.Defmap, f *ast.Fn) (head string, args string) {
+	params, args := gen_export_params(tree, f)
+	ret, _ := c_type(tree, f.RetType.DataType)
+	return ret + " " + f.Id + params, args
+}
+
+// Generates exported wrappers of public functions in namespace ns.
+// Wrappers are not inline, so they are emitted as library symbols.
+func GenExports(tree *ast.Defmap, ns string) string {
+	var cpp strings.Builder
+	cpp.WriteString("namespace ")
+	cpp.WriteString(ns)
+	cpp.WriteString(" {\n")
+	for _, s := range exported_structs(tree) {
+		cpp.WriteString(gen_c_struct(tree, s))
+		cpp.WriteString(gen_c_struct_converters(s))
+	}
+	for _, f := range tree.Fns {
+		if !is_exported_fn(tree, f) {
+			continue
+		}
+		head, args := gen_export_fn_head(tree, f)
+		cpp.WriteString(head)
+		cpp.WriteString(" { return ")
+		if struct_of(f.RetType.DataType) != nil {
+			cpp.WriteString("_to_c(")
+			cpp.WriteString(f.OutId())
+			cpp.WriteString(args)
+			cpp.WriteByte(')')
+		} else {
+			cpp.WriteString(f.OutId())
+			cpp.WriteString(args)
+		}
+		cpp.WriteString("; }\n")
+	}
+	cpp.WriteString("} // namespace ")
+	cpp.WriteString(ns)
+	cpp.WriteByte('\n')
+	return cpp.String()
+}
+
+// Generates declarations of library for C++ header.
+// Header declares C structs of exported structs and prototypes
+// of exported functions, so it includes nothing of runtime.
+func GenHeader(tree *ast.Defmap, ns string) string {
+	var cpp strings.Builder
+	cpp.WriteString("namespace ")
+	cpp.WriteString(ns)
+	cpp.WriteString(" {\n")
+	for _, s := range exported_structs(tree) {
+		cpp.WriteString(gen_c_struct(tree, s))
+	}
+	for _, f := range tree.Fns {
+		if is_exported_fn(tree, f) {
+			head, _ := gen_export_fn_head(tree, f)
+			cpp.WriteString(head)
+			cpp.WriteString(";\n")
+		}
+	}
+	cpp.WriteString("} // namespace ")
+	cpp.WriteString(ns)
+	cpp.WriteByte('\n')
+	return cpp.String()
+}
@@ -0,0 +1,221 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package gen
+
+import (
+	"strings"
+
+	"github.com/DeRuneLabs/jane/ast"
+	"github.com/DeRuneLabs/jane/build"
+	"github.com/DeRuneLabs/jane/lexer"
+	"github.com/DeRuneLabs/jane/types"
+)
+
+// Unit is translation unit of Jane package.
+type Unit struct {
+	// Link string of package, empty for main package.
+	Name string
+	Code string
+	// Paths of C++ headers included by unit, standard headers excluded.
+	Headers []string
+}
+
+// Generating translation units, functions are defined once
+// in unit of their package instead of inline in every unit.
+var units = false
+
+// Reports whether function is template, function or its owner is generic.
+func is_template(f *ast.Fn, owner *ast.Struct) bool {
+	return len(f.Generics) > 0 || owner != nil && len(owner.Generics) > 0
+}
+
+// Reports whether function is defined inline wherever it is declared.
+// Templates are always defined so.
+func is_inline(f *ast.Fn, owner *ast.Struct) bool {
+	if f.IsEntryPoint {
+		return false
+	}
+	return !units || is_template(f, owner)
+}
+
+// Returns package and packages used by it directly or indirectly,
+// in order of used.
+func unit_scope(u *ast.UseDecl, used []*ast.UseDecl) []*ast.UseDecl {
+	by_path := make(map[string]*ast.UseDecl, len(used))
+	for _, u := range used {
+		by_path[u.Path] = u
+	}
+	in := map[string]bool{}
+	var walk func(u *ast.UseDecl)
+	walk = func(u *ast.UseDecl) {
+		if in[u.Path] {
+			return
+		}
+		in[u.Path] = true
+		for _, pu := range u.PackageUses {
+			if dep, ok := by_path[pu.Path]; ok {
+				walk(dep)
+			}
+		}
+	}
+	walk(u)
+	var scope []*ast.UseDecl
+	for _, u := range used {
+		if in[u.Path] {
+			scope = append(scope, u)
+		}
+	}
+	return scope
+}
+
+func gen_extern_globals(dm *ast.Defmap) string {
+	var cpp strings.Builder
+	for _, g := range dm.Globals {
+		if !g.Constant && g.Used && g.Token.Id != lexer.ID_NA && !lexer.IsIgnoreId(g.Id) {
+			cpp.WriteString("extern ")
+			cpp.WriteString(g.DataType.String())
+			cpp.WriteByte(' ')
+			cpp.WriteString(g.OutId())
+			cpp.WriteString(";\n")
+		}
+	}
+	return cpp.String()
+}
+
+// Returns definitions of used functions of defines,
+// inline functions if inline is true, others if not.
+func gen_fn_defs(dm *ast.Defmap, inline bool) string {
+	var cpp strings.Builder
+	for _, f := range dm.Fns {
+		if f.Used && f.Token.Id != lexer.ID_NA && is_inline(f, nil) == inline {
+			cpp.WriteString(gen_line(f.Token))
+			cpp.WriteString(gen_fn(f))
+			cpp.WriteString(gen_line_restore())
+			cpp.WriteString("\n\n")
+		}
+	}
+	return cpp.String()
+}
+
+// Returns declarations of packages of scope and inline definitions of them.
+func gen_unit_decls(scope []*ast.UseDecl, tree *ast.Defmap) string {
+	var defines []*ast.Defmap
+	var structs []*ast.Struct
+	var cpp strings.Builder
+	for _, u := range scope {
+		if u.Cpp {
+			cpp.WriteString(gen_links(&[]*ast.UseDecl{u}))
+			continue
+		}
+		defines = append(defines, u.Defines)
+		structs = append(structs, u.Defines.Structs...)
+	}
+	if tree != nil {
+		defines = append(defines, tree)
+		structs = append(structs, tree.Structs...)
+	}
+	types.OrderStructures(structs)
+	cpp.WriteByte('\n')
+	for _, dm := range defines {
+		cpp.WriteString(_gen_types(dm))
+	}
+	cpp.WriteByte('\n')
+	for _, dm := range defines {
+		cpp.WriteString(_gen_traits(dm))
+	}
+	cpp.WriteString(gen_struct_plain_prototypes(structs))
+	cpp.WriteString(gen_struct_prototypes(structs))
+	for _, dm := range defines {
+		cpp.WriteString(gen_fn_prototypes(dm))
+	}
+	cpp.WriteString("\n\n")
+	for _, dm := range defines {
+		cpp.WriteString(gen_extern_globals(dm))
+	}
+	cpp.WriteString(gen_structs(structs))
+	cpp.WriteString("\n\n")
+	for _, dm := range defines {
+		cpp.WriteString(gen_fn_defs(dm, true))
+	}
+	return cpp.String()
+}
+
+// Returns definitions of globals and functions of package
+// that are not inline.
+func gen_unit_defs(dm *ast.Defmap) string {
+	var cpp strings.Builder
+	cpp.WriteString(_gen_globals(dm))
+	cpp.WriteString("\n\n")
+	for _, s := range dm.Structs {
+		if s.Used && s.Token.Id != lexer.ID_NA {
+			cpp.WriteString(gen_struct_fn_defs(s, false))
+		}
+	}
+	cpp.WriteString(gen_fn_defs(dm, false))
+	return cpp.String()
+}
+
+func unit_headers(scope []*ast.UseDecl) []string {
+	var headers []string
+	for _, u := range scope {
+		if u.Cpp && !build.IsStdHeaderPath(u.Path) {
+			headers = append(headers, u.Path)
+		}
+	}
+	return headers
+}
+
+// Returns translation unit per Jane package of tree and used packages,
+// unit of main package is last.
+//
+// Unit declares its package and packages used by it, and defines
+// functions and globals of its package. Templates and structs are
+// defined by every unit that declares them. So unit of package changes
+// only if package or declarations of packages it uses change,
+// and object of unit is reusable by builds of other packages.
+// Objects of units should be linked in order of units, globals of
+// units are initialized in link order.
+func GenUnits(tree *ast.Defmap, used *[]*ast.UseDecl) []Unit {
+	units = true
+	defer func() { units = false }()
+	var list []Unit
+	for _, u := range *used {
+		if u.Cpp {
+			continue
+		}
+		scope := unit_scope(u, *used)
+		code := gen_unit_decls(scope, nil) + gen_unit_defs(u.Defines)
+		list = append(list, Unit{
+			Name:    u.LinkString,
+			Code:    code,
+			Headers: unit_headers(scope),
+		})
+	}
+	var cpp strings.Builder
+	cpp.WriteString(gen_unit_decls(*used, tree))
+	cpp.WriteString(gen_unit_defs(tree))
+	cpp.WriteString(gen_init_caller(tree, used))
+	list = append(list, Unit{
+		Code:    cpp.String(),
+		Headers: unit_headers(*used),
+	})
+	return list
+}
@@ -0,0 +1,112 @@
+// copyright (c) 2024 arfy slowy - derunelabs
+//
+// permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "software"), to deal
+// in the software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the software, and to permit persons to whom the software is
+// furnished to do so, subject to the following conditions:
+//
+// the above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the software.
+//
+// the software is provided "as is", without warranty of any kind, express or
+// implied, including but not limited to the warranties of merchantability,
+// fitness for a particular purpose and noninfringement. in no event shall the
+// authors or copyright holders be liable for any claim, damages or other
+// liability, whether in an action of contract, tort or otherwise, arising from,
+// out of or in connection with the software or the use or other dealings in the
+// software.
+
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/DeRuneLabs/jane"
+	"github.com/DeRuneLabs/jane/build"
+	"github.com/DeRuneLabs/jane/lexer"
+)
+
+const (
+	buildmode_exe       = "exe"
+	buildmode_staticlib = "staticlib"
+	buildmode_sharedlib = "sharedlib"
+)
+
+var (
+	buildmode = buildmode_exe
+	lib_name  = ""
+)
+
+func parse_buildmode_option(args []string, i *int) {
+	value := get_option_value(args, i)
+	switch value {
+	case "":
+		exit_err(jane.EXIT_USAGE, "missing option value: --buildmode")
+	case buildmode_exe, buildmode_staticlib, buildmode_sharedlib:
+		buildmode = value
+	default:
+		exit_err(jane.EXIT_USAGE, "invalid option value for --buildmode: "+value)
+	}
+}
+
+// Sets name of library, used as namespace of exported defines.
+// Name is taken from manifest, or directory name of package.
+func set_lib_name(path string) {
+	if lib_name == "" {
+		abs, _ := filepath.Abs(path)
+		lib_name = filepath.Base(abs)
+	}
+	runes := []rune(lib_name)
+	for i, r := range runes {
+		if !lexer.IsLetter(r) && r != '_' && (i == 0 || !lexer.IsDecimal(byte(r))) {
+			runes[i] = '_'
+		}
+	}
+	lib_name = string(runes)
+}
+
+// Returns output path of library.
+// Output option is used as exe builds do, library is written into
+// output directory otherwise.
+func get_lib_out() string {
+	if out != "" {
+		return out
+	}
+	name := "lib" + lib_name
+	switch {
+	case buildmode == buildmode_staticlib:
+		name += ".a"
+	case build.IsWindows(build.OS):
+		name += ".dll"
+	case build.IsDarwin(build.OS):
+		name += ".dylib"
+	default:
+		name += ".so"
+	}
+	return filepath.Join(get_out_dir(), name)
+}
+
+// Writes generated header of library next to library.
+// Header has no runtime dependency, it declares exported functions
+// and structs with fixed width C types only.
+func write_lib_header(header string) {
+	guard := "__JANE_LIB_" + strings.ToUpper(lib_name) + "_HPP"
+	var sb strings.Builder
+	sb.WriteString("// Generated by Jane Compiler.\n")
+	sb.WriteString("// Jane Compiler version: ")
+	sb.WriteString(jane.VERSION)
+	sb.WriteString("\n\n#ifndef ")
+	sb.WriteString(guard)
+	sb.WriteString("\n#define ")
+	sb.WriteString(guard)
+	sb.WriteString("\n\n#include <cstdint>\n\n")
+	sb.WriteString(header)
+	sb.WriteString("\n#endif // ")
+	sb.WriteString(guard)
+	sb.WriteByte('\n')
+	dir := filepath.Dir(get_lib_out())
+	write_output(filepath.Join(dir, lib_name+".hpp"), sb.String())
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/DeRuneLabs/jane"
+	"github.com/DeRuneLabs/jane/build"
+)
+
+func parse_lang_option(args []string, i *int) {
+	value := get_option_value(args, i)
+	if value == "" {
+		exit_err(jane.EXIT_USAGE, "missing option value: --lang")
+	}
+	ok, err := build.LoadLocale(jane.LOCALIZATION_PATH, value)
+	if err != nil {
+		exit_err(jane.EXIT_SETUP, "invalid message catalog: "+err.Error())
+	} else if !ok {
+		exit_err(jane.EXIT_USAGE, "invalid option value for --lang: "+value)
+	}
+}
+
+// Reports keys of message catalog that are not translated.
+// Catalog is given by language or path, loaded locale is used if omitted.
+func tool_untranslated(args []string) {
+	if len(args) > 1 {
+		exit_err(jane.EXIT_USAGE, "invalid command: "+args[1])
+	}
+	var path string
+	switch {
+	case len(args) == 0 && build.Locale() == "":
+		exit_err(jane.EXIT_USAGE, "missing language or catalog path")
+	case len(args) == 0:
+		path = filepath.Join(jane.LOCALIZATION_PATH, build.Locale()+build.CATALOG_EXT)
+	case strings.HasSuffix(args[0], build.CATALOG_EXT):
+		path = args[0]
+	default:
+		var ok bool
+		path, ok = build.FindCatalog(jane.LOCALIZATION_PATH, args[0])
+		if !ok {
+			exit_err(jane.EXIT_USAGE, "message catalog not found: "+args[0])
+		}
+	}
+	catalog, err := build.LoadCatalog(path)
+	if err != nil {
+		exit_err(jane.EXIT_SETUP, "invalid message catalog: "+err.Error())
+	}
+	untranslated := build.Untranslated(catalog)
+	var sb strings.Builder
+	sb.WriteString("untranslated keys of ")
+	sb.WriteString(path)
+	sb.WriteString(" (")
+	sb.WriteString(strconv.Itoa(len(untranslated)))
+	sb.WriteString(" of ")
+	sb.WriteString(strconv.Itoa(len(build.ERRORS)))
+	sb.WriteString("):\n")
+	write_key_list(&sb, untranslated)
+	if keys := build.UnknownKeys(catalog); len(keys) > 0 {
+		sb.WriteString("unknown keys:\n")
+		write_key_list(&sb, keys)
+	}
+	if keys := build.MismatchedKeys(catalog); len(keys) > 0 {
+		sb.WriteString("keys with different count of @ placeholders:\n")
+		write_key_list(&sb, keys)
+	}
+	print(sb.String())
+}
+
+func write_key_list(sb *strings.Builder, keys []string) {
+	for _, key := range keys {
+		sb.WriteByte(' ')
+		sb.WriteString(key)
+		sb.WriteByte('\n')
+	}
+}
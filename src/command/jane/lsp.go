@@ -0,0 +1,60 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"os"
+
+	"github.com/DeRuneLabs/jane"
+	"github.com/DeRuneLabs/jane/build"
+	"github.com/DeRuneLabs/jane/lsp"
+)
+
+// Serves Language Server Protocol over standard input and output.
+//
+// Server exits with failure if client exits without shutdown request.
+func lsp_command() {
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		arg, content := get_option(args, &i)
+		switch arg {
+		case "--stdio":
+		default:
+			if content != "" {
+				arg = content
+			}
+			exit_err(jane.EXIT_USAGE, "undefined option: "+arg)
+		}
+		if inline_value != nil {
+			exit_err(jane.EXIT_USAGE, "option does not take value: "+arg)
+		}
+	}
+	inf, err := os.Stat(jane.STDLIB_PATH)
+	if err != nil || !inf.IsDir() {
+		print_log_list([]build.Log{build.FlatErr("stdlib_not_exist")})
+		exit(jane.EXIT_SETUP)
+	}
+	server := lsp.New(os.Stdin, os.Stdout)
+	if !server.Serve() {
+		exit(jane.EXIT_DIAG)
+	}
+	exit(jane.EXIT_SUCCESS)
+}
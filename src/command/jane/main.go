@@ -24,9 +24,12 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/DeRuneLabs/jane"
@@ -36,6 +39,12 @@ import (
 	"github.com/DeRuneLabs/jane/parser"
 )
 
+const (
+	profile_debug   = "debug"
+	profile_release = "release"
+	profile_size    = "size"
+)
+
 const (
 	mode_transpile      = "transpile"
 	mode_compile        = "compiler"
@@ -58,22 +67,67 @@ var (
 	jane_header   = ""
 )
 
+var (
+	target_os   = ""
+	target_arch = ""
+	timestamp   = false
+	diagnostics = build.REPORT_TEXT
+	// Maximum count of reported errors, zero for no limit.
+	max_errors = 50
+)
+
+var (
+	profile       = profile_debug
+	cxxflags      []string
+	ldflags       []string
+	include_dirs  []string
+	libs          []string
+	print_command = false
+)
+
 const (
 	cmd_help    = "help"
 	cmd_version = "version"
 	cmd_tool    = "tool"
+	cmd_run     = "run"
+	cmd_build   = "build"
+	cmd_check   = "check"
+	cmd_fmt     = "fmt"
+	cmd_doc     = "doc"
+	cmd_lsp     = "lsp"
+	cmd_test    = "test"
+	cmd_repl    = "repl"
+	cmd_mod     = "mod"
 )
 
 var HELP_MAP = [...][2]string{
 	{cmd_help, "Show help"},
 	{cmd_version, "Show version"},
 	{cmd_tool, "tool for effective jane"},
+	{cmd_build, "Compile project of manifest or path"},
+	{cmd_run, "Compile and run program"},
+	{cmd_check, "Report diagnostics of packages without compiling"},
+	{cmd_fmt, "Format source files in canonical layout"},
+	{cmd_doc, "Generate documentation of package"},
+	{cmd_lsp, "Serve language server protocol over stdio"},
+	{cmd_test, "Compile and run test functions of package"},
+	{cmd_repl, "Evaluate declarations and expressions interactively"},
+	{cmd_mod, "Vendor and verify dependencies of project"},
 }
 
+const EXIT_HELP = `exit status:
+  0      success
+  1      jane diagnostics reported
+  2      invalid command, option or option value
+  3      missing standard library, entry point or environment
+  4      backend compiler could not be run
+  5      tests failed
+  130    interrupted
+  other  exit status of failed backend compiler`
+
 func help() {
 	if len(os.Args) > 2 {
-		print_error_message("invalid command: " + os.Args[2])
-		return
+		exit_err(jane.EXIT_USAGE, "invalid command: "+os.Args[2])
 	}
 	max := len(HELP_MAP[0][0])
 	for _, k := range HELP_MAP {
@@ -91,22 +145,42 @@ func help() {
 		sb.WriteByte('\n')
 	}
 	println(sb.String()[:sb.Len()-1])
+	println()
+	println(EXIT_HELP)
 }
 
 func print_error_message(msg string) {
 	println(msg)
 }
 
-func exit_err(msg string) {
+// Temporary directories to remove before exit.
+var cleanup_dirs []string
+
+func exit(code int) {
+	for _, dir := range cleanup_dirs {
+		_ = os.RemoveAll(dir)
+	}
+	os.Exit(code)
+}
+
+// Removes temporary directories when process is interrupted or terminated.
+func cleanup_on_signal() {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-c
+		exit(jane.EXIT_INTERRUPT)
+	}()
+}
+
+func exit_err(code int, msg string) {
 	print_error_message(msg)
-	const ERROR_EXIT_CODE = 0
-	os.Exit(ERROR_EXIT_CODE)
+	exit(code)
 }
 
 func version() {
 	if len(os.Args) > 2 {
-		print_error_message("invalid command: " + os.Args[2])
-		return
+		exit_err(jane.EXIT_USAGE, "invalid command: "+os.Args[2])
 	}
 	println("jane version", jane.VERSION)
 }
@@ -119,15 +193,29 @@ func list_horizontal_slice(s []string) string {
 func tool() {
 	if len(os.Args) == 2 {
 		println(`tool commands:
- distos     Lists all supported operating systems
- distarch   Lists all supported architects`)
-		return
-	} else if len(os.Args) > 3 {
-		print_error_message("invalid command: " + os.Args[3])
+ distos         Lists all supported operating systems
+ distarch       Lists all supported architects
+ tokens         Prints tokens of source file
+ ast            Prints syntax tree of source file
+ untranslated   Lists untranslated keys of message catalog`)
 		return
 	}
 	cmd := os.Args[2]
 	switch cmd {
+	case "tokens":
+		tool_tokens(os.Args[3:])
+		return
+	case "ast":
+		tool_ast(os.Args[3:])
+		return
+	case "untranslated":
+		tool_untranslated(os.Args[3:])
+		return
+	}
+	if len(os.Args) > 3 {
+		exit_err(jane.EXIT_USAGE, "invalid command: "+os.Args[3])
+	}
+	switch cmd {
 	case "distos":
 		print("supported operating systems:\n ")
 		println(list_horizontal_slice(build.DISTOS))
@@ -135,7 +223,7 @@ func tool() {
 		print("supported architects:\n ")
 		println(list_horizontal_slice(build.DISTARCH))
 	default:
-		print_error_message("Undefined command: " + cmd)
+		exit_err(jane.EXIT_USAGE, "Undefined command: "+cmd)
 	}
 }
 
@@ -147,6 +235,24 @@ func process_command() bool {
 		version()
 	case cmd_tool:
 		tool()
+	case cmd_build:
+		build_project()
+	case cmd_run:
+		run()
+	case cmd_check:
+		check()
+	case cmd_fmt:
+		fmt_command()
+	case cmd_doc:
+		doc_command()
+	case cmd_lsp:
+		lsp_command()
+	case cmd_test:
+		test_command()
+	case cmd_repl:
+		repl_command()
+	case cmd_mod:
+		mod_command()
 	default:
 		return false
 	}
@@ -166,59 +272,184 @@ func init() {
 	}
 
 	if len(os.Args) < 2 {
-		os.Exit(0)
+		exit_err(jane.EXIT_USAGE, "missing compile path")
 	}
 	if process_command() {
-		os.Exit(0)
+		exit(jane.EXIT_SUCCESS)
 	}
 }
 
 func check_mode() {
 	if mode != mode_transpile && mode != mode_compile {
 		println(build.Errorf("invalid_value_for_key", mode, "mode"))
-		os.Exit(0)
+		exit(jane.EXIT_USAGE)
 	}
 }
 
 func check_compiler() {
 	if compiler != compiler_gcc && compiler != compiler_clang {
 		println(build.Errorf("invalid_value_for_key", compiler, "compiler"))
-		os.Exit(0)
+		exit(jane.EXIT_USAGE)
+	}
+}
+
+func check_target() {
+	if target_os != "" && build.GoosOf(target_os) == "" {
+		println(build.Errorf("invalid_value_for_key", target_os, "target-os"))
+		exit(jane.EXIT_USAGE)
+	}
+	if target_arch != "" && build.GoarchOf(target_arch) == "" {
+		println(build.Errorf("invalid_value_for_key", target_arch, "target-arch"))
+		exit(jane.EXIT_USAGE)
+	}
+	_ = build.SetTarget(target_os, target_arch)
+	// Only clang supports --target, gcc has a separate
+	// compiler for each target.
+	if compiler == compiler_gcc && build.IsCross() {
+		cross := get_cross_gcc()
+		if _, err := exec.LookPath(cross); err != nil {
+			exit_err(jane.EXIT_USAGE, "cross compilation to "+build.OS+"/"+build.ARCH+
+				" with gcc requires "+cross+", install it or use --compiler clang")
+		}
+		compiler_path = cross
+	}
+}
+
+// Returns name of cross gcc for current target.
+func get_cross_gcc() string {
+	triple := build.Triple()
+	if build.IsWindows(build.OS) {
+		triple = strings.Replace(triple, "-pc-windows-gnu", "-w64-mingw32", 1)
+	}
+	return triple + "-" + compiler_path_gcc
+}
+
+func check_profile() {
+	if profile != profile_debug && profile != profile_release && profile != profile_size {
+		println(build.Errorf("invalid_value_for_key", profile, "profile"))
+		exit(jane.EXIT_USAGE)
 	}
 }
 
 func set() {
 	check_mode()
 	check_compiler()
+	check_profile()
+	check_target()
 }
 
 func print_logs(p *parser.Parser) bool {
-	var str strings.Builder
-	for _, l := range p.Errors {
-		str.WriteString(l.String())
-		str.WriteByte('\n')
+	return print_log_list(p.Errors)
+}
+
+func print_log_list(logs []build.Log) bool {
+	return print_log_list_source(logs, lexer.SourceLine)
+}
+
+// Prints logs, source lines of snippets are taken from source.
+func print_log_list_source(logs []build.Log, source func(string, int) (string, bool)) bool {
+	failed := build.HasErrors(logs)
+	logs = build.CapErrors(logs, max_errors)
+	switch diagnostics {
+	case build.REPORT_JSON:
+		fmt.Println(build.LogsToJSON(logs))
+	case build.REPORT_SARIF:
+		fmt.Println(build.LogsToSARIF(logs))
+	case build.REPORT_SHORT:
+		var str strings.Builder
+		for _, l := range logs {
+			str.WriteString(l.String())
+			str.WriteByte('\n')
+		}
+		print(str.String())
+	default:
+		r := build.Renderer{Source: source, Color: use_color()}
+		var str strings.Builder
+		for _, l := range logs {
+			str.WriteString(r.Render(l))
+			str.WriteString("\n\n")
+		}
+		print(str.String())
 	}
-	print(str.String())
-	return len(p.Errors) > 0
+	return failed
 }
 
-func append_standard(obj_code *string) {
-	y, m, d := time.Now().Date()
-	h, min, _ := time.Now().Clock()
-	timeStr := fmt.Sprintf("%d/%d/%d %d.%d (DD/MM/YYYY) (HH.MM)",
-		d, m, y, h, min)
+// Reports whether diagnostics are colored.
+// Logs are printed to stderr, so colors are used if stderr
+// is a terminal and NO_COLOR environment variable is not set.
+func use_color() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stderr.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Returns generation date for the output header.
+// Date is not included unless timestamp option is enabled or
+// SOURCE_DATE_EPOCH is set, to keep output reproducible.
+func get_timestamp() (string, bool) {
+	var t time.Time
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		sec, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			exit_err(jane.EXIT_USAGE, "invalid SOURCE_DATE_EPOCH: "+epoch)
+		}
+		t = time.Unix(sec, 0).UTC()
+	} else if timestamp {
+		t = time.Now()
+	} else {
+		return "", false
+	}
+	y, m, d := t.Date()
+	h, min, _ := t.Clock()
+	return fmt.Sprintf("%d/%d/%d %d.%d (DD/MM/YYYY) (HH.MM)",
+		d, m, y, h, min), true
+}
+
+// Returns exit code for reported logs.
+func logs_exit_code(logs []build.Log) int {
+	for _, l := range logs {
+		switch l.Key {
+		case "stdlib_not_exist", "no_entry_point":
+			return jane.EXIT_SETUP
+		}
+	}
+	return jane.EXIT_DIAG
+}
+
+// Returns head of generated file, comment and include of runtime.
+func gen_standard_head() string {
 	var sb strings.Builder
 	sb.WriteString("// Generated by Jane Compiler.\n")
 	sb.WriteString("// Jane Compiler version: ")
 	sb.WriteString(jane.VERSION)
 	sb.WriteByte('\n')
-	sb.WriteString("// Date: ")
-	sb.WriteString(timeStr)
-	sb.WriteString("\n\n#include \"")
+	if timeStr, ok := get_timestamp(); ok {
+		sb.WriteString("// Date: ")
+		sb.WriteString(timeStr)
+		sb.WriteByte('\n')
+	}
+	sb.WriteString("\n#include \"")
 	sb.WriteString(jane_header)
 	sb.WriteString("\"\n\n")
-	sb.WriteString(*obj_code)
-	sb.WriteString(`
+	return sb.String()
+}
+
+// Returns tail of generated file of main package,
+// main function of program or initializer of library.
+func gen_standard_tail() string {
+	if testing {
+		return gen_test_main()
+	}
+	if buildmode != buildmode_exe {
+		return `
+
+__attribute__((constructor)) static void __jane_library_initializer(void) {
+  __jane_call_package_initializers();
+}`
+	}
+	return `
 
 int main(int argc, char *argv[]) {
   std::set_terminate( &__jane_terminate_handler );
@@ -227,29 +458,31 @@ int main(int argc, char *argv[]) {
   __jane_call_package_initializers();
   JANE_ID(main)();
   return(EXIT_SUCCESS);
-}`)
-	*obj_code = sb.String()
+}`
+}
+
+func append_standard(obj_code *string) {
+	*obj_code = gen_standard_head() + *obj_code + gen_standard_tail()
 }
 
 func write_output(path, content string) {
 	dir := filepath.Dir(path)
 	err := os.MkdirAll(dir, 0o777)
 	if err != nil {
-		exit_err(err.Error())
+		exit_err(jane.EXIT_SETUP, err.Error())
 	}
 	f, err := os.Create(path)
 	if err != nil {
-		exit_err(err.Error())
+		exit_err(jane.EXIT_SETUP, err.Error())
 	}
 	_, err = f.WriteString(content)
 	if err != nil {
-		exit_err(err.Error())
+		exit_err(jane.EXIT_SETUP, err.Error())
 	}
 	_ = f.Close()
 }
 
 func compile(path string) *parser.Parser {
-	set()
 	inf, err := os.Stat(jane.STDLIB_PATH)
 	if err != nil || !inf.IsDir() {
 		p := &parser.Parser{}
@@ -263,9 +496,18 @@ func compile(path string) *parser.Parser {
 	}
 	p, err_msg := parser.ParsePackage(path, false)
 	if err_msg != "" {
-		exit_err(err_msg)
+		exit_err(jane.EXIT_SETUP, err_msg)
 	}
 
+	if buildmode != buildmode_exe {
+		gen.UseExports(p.Defines)
+		p.Errors = append(p.Errors, gen.CheckExports(p.Defines)...)
+		return p
+	}
+	if testing {
+		use_tests(p)
+		return p
+	}
 	f, _, _ := p.Defines.FnById(jane.ENTRY_POINT, nil)
 	if f == nil {
 		p.PushErr("no_entry_point")
@@ -277,71 +519,229 @@ func compile(path string) *parser.Parser {
 	return p
 }
 
-func gen_compile_cmd(source_path string) (c string, cmd string) {
-	var cpp strings.Builder
-	cpp.WriteString("-g -O0 -Wno-narrowing ")
+func get_profile_flags() []string {
+	switch profile {
+	case profile_release:
+		return []string{"-O2", "-DNDEBUG"}
+	case profile_size:
+		return []string{"-Os", "-DNDEBUG"}
+	default:
+		return []string{"-g", "-O0"}
+	}
+}
+
+// Returns backend flags used for compiling source files.
+func gen_compile_flags() (args []string) {
+	args = append(args, get_profile_flags()...)
+	args = append(args, "-Wno-narrowing")
+	args = append(args, gen_target_flags()...)
+	args = append(args, gen_pic_flags()...)
+	args = append(args, cxxflags...)
+	for _, dir := range include_dirs {
+		args = append(args, "-I", dir)
+	}
+	return args
+}
+
+func gen_pic_flags() []string {
+	if buildmode != buildmode_exe && !build.IsWindows(build.OS) {
+		return []string{"-fPIC"}
+	}
+	return nil
+}
+
+func gen_target_flags() []string {
+	if compiler == compiler_clang && build.IsCross() {
+		return []string{"--target=" + build.Triple()}
+	}
+	return nil
+}
+
+// Returns backend flags used for linking output.
+func gen_link_flags() (args []string) {
+	args = append(args, ldflags...)
+	for _, lib := range libs {
+		args = append(args, "-l"+lib)
+	}
+	return args
+}
+
+func gen_compile_cmd(source_path string) (c string, args []string) {
+	args = gen_compile_flags()
 	if out != "" {
-		cpp.WriteString("-o ")
-		cpp.WriteString(out)
-		cpp.WriteByte(' ')
+		args = append(args, "-o", out)
 	}
-	cpp.WriteString(source_path)
-	return compiler_path, cpp.String()
+	args = append(args, source_path)
+	args = append(args, gen_link_flags()...)
+	return compiler_path, args
+}
+
+// Returns command as printable shell command line.
+func cmd_string(c string, args []string) string {
+	var sb strings.Builder
+	sb.WriteString(c)
+	for _, arg := range args {
+		sb.WriteByte(' ')
+		if arg == "" || strings.ContainsAny(arg, " \t\"'\\$") {
+			sb.WriteString(strconv.Quote(arg))
+		} else {
+			sb.WriteString(arg)
+		}
+	}
+	return sb.String()
+}
+
+// Returns output directory of generated files.
+func get_out_dir() string {
+	if filepath.IsAbs(out_dir) {
+		return out_dir
+	}
+	return filepath.Join(jane.WORKING_PATH, out_dir)
 }
 
 func do_spell(cpp string) {
-	path := filepath.Join(jane.WORKING_PATH, out_dir)
-	path = filepath.Join(path, out_name)
+	path := filepath.Join(get_out_dir(), out_name)
+	cpp = gen.RestoreLines(cpp, path)
 	write_output(path, cpp)
-	switch mode {
-	case mode_compile:
-		c, cmd := gen_compile_cmd(path)
-		println(c + " " + cmd)
-		entries := strings.SplitN(cmd, " ", -1)
-		command := exec.Command(c, entries...)
-		err := command.Start()
-		if err != nil {
-			println(err.Error())
+	if compile_commands {
+		write_compile_commands(filepath.Dir(path), []string{path})
+	}
+	if mode != mode_compile {
+		return
+	}
+	if buildmode == buildmode_exe && (!use_cache || print_command) {
+		run_backend(gen_compile_cmd(path))
+		return
+	}
+	link_output(compile_object(path, dep_headers(cache_deps)))
+}
+
+// Returns name of generated file of unit.
+// Unit of main package is named as output, others are
+// named by their package next to it.
+func unit_file_name(u gen.Unit) string {
+	if u.Name == "" {
+		return out_name
+	}
+	ext := filepath.Ext(out_name)
+	name := strings.ReplaceAll(u.Name, lexer.KND_DBLCOLON, "_")
+	return strings.TrimSuffix(out_name, ext) + "." + name + ext
+}
+
+// Writes units into output directory, compiles them and links objects.
+// Tail of generated file is appended to unit of main package, last unit.
+func spell_units(units []gen.Unit) {
+	dir := get_out_dir()
+	paths := make([]string, len(units))
+	for i, u := range units {
+		code := gen_standard_head() + u.Code
+		if i+1 == len(units) {
+			code += gen_standard_tail()
 		}
-		err = command.Wait()
-		if err != nil {
-			println(err.Error())
+		paths[i] = filepath.Join(dir, unit_file_name(u))
+		write_output(paths[i], gen.RestoreLines(code, paths[i]))
+	}
+	if compile_commands {
+		write_compile_commands(dir, paths)
+	}
+	objs := make([]string, len(units))
+	for i, u := range units {
+		objs[i] = compile_object(paths[i], u.Headers)
+	}
+	link_output(objs...)
+}
+
+// Links object files into output of build mode.
+// Objects are linked in given order.
+func link_output(objs ...string) {
+	switch buildmode {
+	case buildmode_staticlib:
+		run_backend("ar", append([]string{"rcs", get_lib_out()}, objs...))
+	case buildmode_sharedlib:
+		args := gen_target_flags()
+		args = append(args, "-shared", "-o", get_lib_out())
+		args = append(args, objs...)
+		args = append(args, gen_link_flags()...)
+		run_backend(compiler_path, args)
+	default:
+		args := gen_target_flags()
+		if out != "" {
+			args = append(args, "-o", out)
 		}
+		args = append(args, objs...)
+		args = append(args, gen_link_flags()...)
+		run_backend(compiler_path, args)
 	}
 }
 
-func get_option(i *int) (arg string, content string) {
-	for ; *i < len(os.Args); *i++ {
-		arg = os.Args[*i]
+// Runs backend compiler command.
+// Exits with exit status of backend compiler if it fails,
+// or EXIT_BACKEND if it could not be run.
+// Command is just printed if print_command is enabled.
+func run_backend(c string, args []string) {
+	if print_command {
+		fmt.Println(cmd_string(c, args))
+		return
+	}
+	println(cmd_string(c, args))
+	command := exec.Command(c, args...)
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	err := command.Run()
+	if err != nil {
+		if status, ok := err.(*exec.ExitError); ok && status.ExitCode() > 0 {
+			exit_err(status.ExitCode(), "backend compiler failed with exit status "+strconv.Itoa(status.ExitCode()))
+		}
+		exit_err(jane.EXIT_BACKEND, err.Error())
+	}
+}
+
+// Short options that take value joined to option, like -Ifoo and -lm.
+var joined_options = [...]string{"-I", "-l"}
+
+func get_option(args []string, i *int) (arg string, content string) {
+	for ; *i < len(args); *i++ {
+		arg = args[*i]
+		for _, option := range joined_options {
+			if len(arg) > len(option) && strings.HasPrefix(arg, option) {
+				value := arg[len(option):]
+				inline_value = &value
+				return option, ""
+			}
+		}
 		j := 0
 		runes := []rune(arg)
 		r := runes[j]
 		if r != '-' {
-			content += arg
-			arg = ""
-			continue
+			return "", arg
 		}
 		j++
 		if j >= len(runes) {
-			exit_err("undefined syntax: " + arg)
+			exit_err(jane.EXIT_USAGE, "undefined syntax: "+arg)
 		}
 		r = runes[j]
 		if r == '-' {
 			j++
 			if j >= len(runes) {
-				exit_err("undefined syntax: " + arg)
+				exit_err(jane.EXIT_USAGE, "undefined syntax: "+arg)
 			}
 			r = runes[j]
 		}
 		if !lexer.IsIdentifierRune(string(r)) {
-			exit_err("undefined syntax: " + arg)
+			exit_err(jane.EXIT_USAGE, "undefined syntax: "+arg)
 		}
 		j++
 		for ; j < len(runes); j++ {
 			r = runes[j]
+			if r == '=' {
+				value := string(runes[j+1:])
+				inline_value = &value
+				arg = string(runes[:j])
+				break
+			}
 			if !lexer.IsSpace(r) && !lexer.IsLetter(r) &&
 				!lexer.IsDecimal(byte(r)) && r != '_' && r != '-' {
-				exit_err("undefined syntax: " + string(runes[j:]))
+				exit_err(jane.EXIT_USAGE, "undefined syntax: "+string(runes[j:]))
 			}
 		}
 		break
@@ -349,27 +749,35 @@ func get_option(i *int) (arg string, content string) {
 	return
 }
 
-func get_option_value(i *int) string {
+// Value of option given in --option=value form.
+var inline_value *string
+
+func get_option_value(args []string, i *int) string {
+	if inline_value != nil {
+		value := *inline_value
+		inline_value = nil
+		return value
+	}
 	*i++
-	if *i < len(os.Args) {
-		arg := os.Args[*i]
+	if *i < len(args) {
+		arg := args[*i]
 		return arg
 	}
 	return ""
 }
 
-func parse_out_option(i *int) {
-	value := get_option_value(i)
+func parse_out_option(args []string, i *int) {
+	value := get_option_value(args, i)
 	if value == "" {
-		exit_err("missing option value: -o --out")
+		exit_err(jane.EXIT_USAGE, "missing option value: -o --out")
 	}
 	out = value
 }
 
-func parse_compiler_option(i *int) {
-	value := get_option_value(i)
+func parse_compiler_option(args []string, i *int) {
+	value := get_option_value(args, i)
 	if value == "" {
-		exit_err("missing option value: --compiler")
+		exit_err(jane.EXIT_USAGE, "missing option value: --compiler")
 	}
 	switch value {
 	case compiler_clang:
@@ -377,51 +785,310 @@ func parse_compiler_option(i *int) {
 	case compiler_gcc:
 		compiler_path = compiler_path_gcc
 	default:
-		exit_err("invalid option value for --compiler: " + value)
+		exit_err(jane.EXIT_USAGE, "invalid option value for --compiler: "+value)
 	}
 	compiler = value
 }
 
-func parse_options() string {
-	cmd := ""
-	i := 1
-	for ; i < len(os.Args); i++ {
-		arg, content := get_option(&i)
-		cmd += content
+func parse_target_os_option(args []string, i *int) {
+	value := get_option_value(args, i)
+	if value == "" {
+		exit_err(jane.EXIT_USAGE, "missing option value: --target-os")
+	}
+	target_os = value
+}
+
+func parse_target_arch_option(args []string, i *int) {
+	value := get_option_value(args, i)
+	if value == "" {
+		exit_err(jane.EXIT_USAGE, "missing option value: --target-arch")
+	}
+	target_arch = value
+}
+
+func parse_diagnostics_option(args []string, i *int) {
+	value := get_option_value(args, i)
+	switch value {
+	case "":
+		exit_err(jane.EXIT_USAGE, "missing option value: --diagnostics")
+	case build.REPORT_TEXT, build.REPORT_SHORT, build.REPORT_JSON, build.REPORT_SARIF:
+		diagnostics = value
+	default:
+		exit_err(jane.EXIT_USAGE, "invalid option value for --diagnostics: "+value)
+	}
+}
+
+func parse_profile_option(args []string, i *int) {
+	value := get_option_value(args, i)
+	switch value {
+	case "":
+		exit_err(jane.EXIT_USAGE, "missing option value: --profile")
+	case profile_debug, profile_release, profile_size:
+		profile = value
+	default:
+		exit_err(jane.EXIT_USAGE, "invalid option value for --profile: "+value)
+	}
+}
+
+// Appends flags of option value to list.
+// Flags are split like shell does, see split_flags.
+func parse_flags_option(args []string, i *int, option string, list *[]string) {
+	value := get_option_value(args, i)
+	if value == "" {
+		exit_err(jane.EXIT_USAGE, "missing option value: "+option)
+	}
+	flags, ok := split_flags(value)
+	if !ok {
+		exit_err(jane.EXIT_USAGE, "unterminated quote in option value: "+option)
+	}
+	*list = append(*list, flags...)
+}
+
+// Returns whitespace separated flags of value.
+// Quotes and backslash escapes are handled like POSIX shell,
+// so -DNAME="a b" and '/path with spaces' are single flags.
+// Reports false if a quote is not terminated.
+func split_flags(value string) ([]string, bool) {
+	var flags []string
+	var flag strings.Builder
+	in_flag := false
+	runes := []rune(value)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch r {
+		case ' ', '\t', '\n':
+			if in_flag {
+				flags = append(flags, flag.String())
+				flag.Reset()
+				in_flag = false
+			}
+			continue
+		case '\\':
+			i++
+			if i == len(runes) {
+				return nil, false
+			}
+			flag.WriteRune(runes[i])
+		case '\'':
+			i++
+			for i < len(runes) && runes[i] != '\'' {
+				flag.WriteRune(runes[i])
+				i++
+			}
+			if i == len(runes) {
+				return nil, false
+			}
+		case '"':
+			i++
+			for i < len(runes) && runes[i] != '"' {
+				// Backslash escapes only special characters in double quotes.
+				if runes[i] == '\\' && i+1 < len(runes) &&
+					strings.ContainsRune("\"\\$`", runes[i+1]) {
+					i++
+				}
+				flag.WriteRune(runes[i])
+				i++
+			}
+			if i == len(runes) {
+				return nil, false
+			}
+		default:
+			flag.WriteRune(r)
+		}
+		in_flag = true
+	}
+	if in_flag {
+		flags = append(flags, flag.String())
+	}
+	return flags, true
+}
+
+// Appends option value to list.
+func parse_list_option(args []string, i *int, option string, list *[]string) {
+	value := get_option_value(args, i)
+	if value == "" {
+		exit_err(jane.EXIT_USAGE, "missing option value: "+option)
+	}
+	*list = append(*list, value)
+}
+
+func parse_max_errors_option(args []string, i *int) {
+	value := get_option_value(args, i)
+	if value == "" {
+		exit_err(jane.EXIT_USAGE, "missing option value: --max-errors")
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		exit_err(jane.EXIT_USAGE, "invalid option value for --max-errors: "+value)
+	}
+	max_errors = n
+}
+
+// Applies level of comma separated diagnostic keys of option value.
+func parse_lint_option(args []string, i *int, option string, apply func(key string) bool) {
+	value := get_option_value(args, i)
+	if value == "" {
+		exit_err(jane.EXIT_USAGE, "missing option value: "+option)
+	}
+	apply_lints(strings.Split(value, ","), option, apply)
+}
+
+func apply_lints(keys []string, option string, apply func(key string) bool) {
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if !apply(key) {
+			exit_err(jane.EXIT_USAGE, "invalid option value for "+option+": "+key)
+		}
+	}
+}
+
+// Parses options of args.
+// Returns paths and option arguments given in args.
+func parse_args(args []string) (paths []string, options []string) {
+	i := 0
+	for ; i < len(args); i++ {
+		start := i
+		arg, content := get_option(args, &i)
+		if content != "" {
+			paths = append(paths, content)
+		}
 		switch arg {
 		case "":
 		case "-o", "--out":
-			parse_out_option(&i)
+			parse_out_option(args, &i)
 		case "-t", "--transpile":
 			mode = mode_transpile
 		case "-c", "--compile":
 			mode = mode_compile
 		case "--compiler":
-			parse_compiler_option(&i)
+			parse_compiler_option(args, &i)
+		case "--target-os":
+			parse_target_os_option(args, &i)
+		case "--target-arch":
+			parse_target_arch_option(args, &i)
+		case "--timestamp":
+			timestamp = true
+		case "--diagnostics":
+			parse_diagnostics_option(args, &i)
+		case "--profile":
+			parse_profile_option(args, &i)
+		case "--cxxflags":
+			parse_flags_option(args, &i, arg, &cxxflags)
+		case "--ldflags":
+			parse_flags_option(args, &i, arg, &ldflags)
+		case "-I", "--include":
+			parse_list_option(args, &i, arg, &include_dirs)
+		case "-l", "--lib":
+			parse_list_option(args, &i, arg, &libs)
+		case "--print-command":
+			print_command = true
+		case "--cache-dir":
+			parse_cache_dir_option(args, &i)
+		case "--no-cache":
+			use_cache = false
+		case "--buildmode":
+			parse_buildmode_option(args, &i)
+		case "--line-directives":
+			gen.LineDirectives = true
+		case "--compile-commands":
+			compile_commands = true
+		case "--max-errors":
+			parse_max_errors_option(args, &i)
+		case "--lang":
+			parse_lang_option(args, &i)
+		case "-W", "--warn":
+			parse_lint_option(args, &i, arg, build.Warn)
+		case "--allow":
+			parse_lint_option(args, &i, arg, build.Allow)
+		case "--deny":
+			parse_lint_option(args, &i, arg, build.Deny)
 		default:
-			exit_err("undefined option: " + arg)
+			exit_err(jane.EXIT_USAGE, "undefined option: "+arg)
+		}
+		if inline_value != nil {
+			exit_err(jane.EXIT_USAGE, "option does not take value: "+arg)
+		}
+		if arg != "" {
+			options = append(options, args[start:i+1]...)
 		}
 	}
-	cmd = strings.TrimSpace(cmd)
-	return cmd
+	return paths, options
 }
 
-func main() {
-	cmd := parse_options()
-	if cmd == "" {
-		exit_err("missing compile path")
+// Parses options of args and returns compile path.
+func parse_options(args []string) string {
+	paths, _ := parse_args(args)
+	switch len(paths) {
+	case 0:
+		return ""
+	case 1:
+		return strings.TrimSpace(paths[0])
+	default:
+		exit_err(jane.EXIT_USAGE, "too many compile paths: "+strings.Join(paths[1:], " "))
+		return ""
 	}
+}
 
-	p := compile(cmd)
-	if p == nil {
-		return
+// Reports whether build generates translation unit per package.
+// Units are generated for cached builds of executables,
+// objects of them are reused by later builds.
+func use_units() bool {
+	return mode == mode_compile && buildmode == buildmode_exe && !testing &&
+		use_cache && !print_command && get_cache_dir() != ""
+}
+
+// Compiles package of path into units and spells them.
+// Units are taken from cache if sources are unchanged.
+func build_units(path string) {
+	units, logs, ok := load_cached_units(path)
+	if ok {
+		// Cached builds have no errors, only warnings and notes of build.
+		// Print them as uncached builds do, also keeps machine-readable
+		// reports consistent.
+		print_log_list(logs)
+	} else {
+		p := compile(path)
+		if print_logs(p) {
+			exit(logs_exit_code(p.Errors))
+		}
+		p.WrapPackage()
+		units = gen.GenUnits(p.Defines, p.Used)
+		store_cached_units(path, p, units)
 	}
+	spell_units(units)
+}
 
+// Compiles package of path and spells output.
+func build_package(path string) {
+	set()
+	if use_units() {
+		build_units(path)
+		return
+	}
+	p := compile(path)
 	if print_logs(p) {
+		exit(logs_exit_code(p.Errors))
+	}
+	if testing && len(test_fns) == 0 {
+		// Nothing to compile, test command reports it.
 		return
 	}
 	p.WrapPackage()
 	obj_code := gen.Gen(p.Defines, p.Used)
+	if buildmode != buildmode_exe {
+		set_lib_name(path)
+		cache_deps = get_deps(path, p)
+		obj_code += "\n\n" + gen.GenExports(p.Defines, lib_name)
+		write_lib_header(gen.GenHeader(p.Defines, lib_name))
+	}
 	append_standard(&obj_code)
 	do_spell(obj_code)
 }
+
+func main() {
+	cmd := parse_options(os.Args[1:])
+	if cmd == "" {
+		exit_err(jane.EXIT_USAGE, "missing compile path")
+	}
+	build_package(cmd)
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/DeRuneLabs/jane"
+	"github.com/DeRuneLabs/jane/manifest"
+)
+
+// Manages modules of project manifest.
+func mod_command() {
+	if len(os.Args) == 2 {
+		println(`mod commands:
+ vendor     Copies dependencies into vendor directory and writes lock file
+ verify     Checks vendored dependencies against lock file`)
+		return
+	}
+	if len(os.Args) > 3 {
+		exit_err(jane.EXIT_USAGE, "invalid command: "+os.Args[3])
+	}
+	cmd := os.Args[2]
+	switch cmd {
+	case "vendor":
+		lock, err := manifest.Vendor(load_project_manifest())
+		if err != nil {
+			exit_err(jane.EXIT_SETUP, err.Error())
+		}
+		for _, m := range lock.Modules {
+			fmt.Println(m.Name, m.Version, m.Hash)
+		}
+	case "verify":
+		err := manifest.Verify(load_project_manifest())
+		if err != nil {
+			exit_err(jane.EXIT_SETUP, err.Error())
+		}
+		fmt.Println("all modules verified")
+	default:
+		exit_err(jane.EXIT_USAGE, "Undefined command: "+cmd)
+	}
+}
+
+// Returns manifest of project in working directory.
+func load_project_manifest() *manifest.Manifest {
+	path := manifest.Find(jane.WORKING_PATH)
+	if path == "" {
+		exit_err(jane.EXIT_USAGE, manifest.FILE_NAME+" is not found")
+	}
+	m, err := manifest.Load(path)
+	if err != nil {
+		exit_err(jane.EXIT_USAGE, err.Error())
+	}
+	return m
+}
@@ -0,0 +1,493 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/DeRuneLabs/jane"
+	"github.com/DeRuneLabs/jane/ast"
+	"github.com/DeRuneLabs/jane/build"
+	"github.com/DeRuneLabs/jane/lexer"
+	"github.com/DeRuneLabs/jane/parser"
+)
+
+const (
+	REPL_PROMPT      = ">>> "
+	REPL_CONT_PROMPT = "... "
+	// Line printed by program before output of evaluated input.
+	// Output of previous statements is discarded until this line.
+	REPL_MARKER = "__jane_repl_output__"
+	// Variable of evaluated expression.
+	REPL_VALUE = "__jane_repl_value__"
+)
+
+const REPL_HELP = `repl commands:
+ :help      Show help
+ :session   Show declarations and statements of session
+ :reset     Clear session
+ :quit      Exit repl
+
+Functions, types and constants are declared in package scope.
+Other inputs are statements of main function; let and mut declare
+variables of main, which functions cannot use.
+
+Every input compiles and runs the whole session program again:
+statements of session are executed again before each input, so their
+side effects, like output, file writes or reads of stdin, are repeated.
+Output of previous statements is not shown. Use :reset to clear session.`
+
+// Session of repl.
+//
+// Declarations are written into own file in session directory.
+// Statements are kept in order and executed by main function
+// before input, so state of session is same for every program.
+type repl_session struct {
+	dir   string
+	args  []string
+	decls []string
+	stmts []string
+	vars  int // Count of variables declared by statements.
+}
+
+// Reads inputs from stdin and evaluates them in persistent session.
+//
+// Declarations and statements are kept in session.
+// Constant expressions are evaluated by parser, other inputs are
+// compiled and executed with run command. Options are passed to run command.
+func repl_command() {
+	inf, err := os.Stat(jane.STDLIB_PATH)
+	if err != nil || !inf.IsDir() {
+		print_log_list([]build.Log{build.FlatErr("stdlib_not_exist")})
+		exit(jane.EXIT_SETUP)
+	}
+	tmp, err := os.MkdirTemp("", "jane-repl-")
+	if err != nil {
+		exit_err(jane.EXIT_SETUP, err.Error())
+	}
+	cleanup_dirs = append(cleanup_dirs, tmp)
+	cleanup_on_signal()
+	_ = load_project()
+
+	s := &repl_session{dir: tmp, args: os.Args[2:]}
+	check_repl_args(s.args)
+	fmt.Println("jane", jane.VERSION, "repl, type :help for commands")
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		src, ok := read_repl_input(scanner)
+		if !ok {
+			fmt.Println()
+			break
+		}
+		switch strings.TrimSpace(src) {
+		case "":
+		case ":help":
+			fmt.Println(REPL_HELP)
+		case ":session":
+			s.print()
+		case ":reset":
+			s.reset()
+		case ":quit", ":q":
+			exit(jane.EXIT_SUCCESS)
+		default:
+			s.eval(src)
+		}
+	}
+	exit(jane.EXIT_SUCCESS)
+}
+
+// Checks options passed to run command, so invalid options
+// are reported once instead of for every input.
+func check_repl_args(args []string) {
+	paths, _ := parse_args(args)
+	if len(paths) > 0 {
+		exit_err(jane.EXIT_USAGE, "repl does not take path: "+paths[0])
+	}
+	if mode != mode_compile {
+		exit_err(jane.EXIT_USAGE, "repl does not support transpile mode")
+	}
+	if buildmode != buildmode_exe {
+		exit_err(jane.EXIT_USAGE, "repl does not support library build modes")
+	}
+	set()
+}
+
+// Reads input from scanner.
+// Lines are read until all braces are closed.
+func read_repl_input(scanner *bufio.Scanner) (string, bool) {
+	var src strings.Builder
+	fmt.Print(REPL_PROMPT)
+	for scanner.Scan() {
+		src.WriteString(scanner.Text())
+		src.WriteByte('\n')
+		if repl_brace_depth(src.String()) <= 0 {
+			return src.String(), true
+		}
+		fmt.Print(REPL_CONT_PROMPT)
+	}
+	if src.Len() > 0 {
+		return src.String(), true
+	}
+	return "", false
+}
+
+// Returns count of unclosed braces of source.
+func repl_brace_depth(src string) int {
+	l := lexer.New(lexer.NewFile(""))
+	depth := 0
+	for _, tok := range l.LexData([]byte(src)) {
+		if tok.Id != lexer.ID_BRACE {
+			continue
+		}
+		switch tok.Kind {
+		case lexer.KND_LPAREN, lexer.KND_LBRACE, lexer.KND_LBRACKET:
+			depth++
+		default:
+			depth--
+		}
+	}
+	return depth
+}
+
+// Reports whether tokens are declaration of package scope.
+func is_repl_decl(toks []lexer.Token) bool {
+	switch toks[0].Id {
+	case lexer.ID_USE, lexer.ID_FN, lexer.ID_CONST,
+		lexer.ID_TYPE, lexer.ID_ENUM, lexer.ID_STRUCT, lexer.ID_TRAIT,
+		lexer.ID_IMPL, lexer.ID_CPP, lexer.ID_PUB, lexer.ID_COMMENT:
+		return true
+	case lexer.ID_UNSAFE:
+		return len(toks) > 1 && toks[1].Id == lexer.ID_FN
+	}
+	return false
+}
+
+// Reports whether tokens are statement instead of expression.
+func is_repl_stmt(toks []lexer.Token) bool {
+	switch toks[0].Id {
+	case lexer.ID_LET, lexer.ID_MUT,
+		lexer.ID_RET, lexer.ID_ITER, lexer.ID_BREAK, lexer.ID_CONTINUE,
+		lexer.ID_IF, lexer.ID_GOTO, lexer.ID_CO, lexer.ID_MATCH,
+		lexer.ID_UNSAFE, lexer.ID_DEFER, lexer.ID_FALL:
+		return true
+	}
+	if toks[0].Id == lexer.ID_BRACE && toks[0].Kind == lexer.KND_LBRACE {
+		return true
+	}
+	return ast.CheckAssignTokens(toks)
+}
+
+// Evaluates input in session.
+func (s *repl_session) eval(src string) {
+	l := lexer.New(lexer.NewFile(filepath.Join(s.dir, "input"+jane.EXT)))
+	toks := l.LexData([]byte(src))
+	if len(l.Logs) > 0 {
+		s.print_logs(l.Logs)
+		return
+	}
+	if len(toks) == 0 {
+		return
+	}
+	switch {
+	case is_repl_decl(toks):
+		s.declare(src)
+	case is_repl_stmt(toks):
+		s.exec_stmt(src)
+	default:
+		s.eval_expr(src)
+	}
+}
+
+// Appends declaration to session if session is still valid with it.
+// Reports values of declared constants.
+func (s *repl_session) declare(src string) {
+	path := filepath.Join(s.dir, "decl_"+strconv.Itoa(len(s.decls)+1)+jane.EXT)
+	if !s.write(path, src) {
+		return
+	}
+	s.write_main()
+	p := s.parse()
+	if p == nil || build.HasErrors(p.Errors) {
+		if p != nil {
+			s.print_logs(p.Errors)
+		}
+		_ = os.Remove(path)
+		return
+	}
+	s.decls = append(s.decls, src)
+	for _, g := range p.Defines.Globals {
+		if g.Token.File == nil || g.Token.File.Path() != path {
+			continue
+		}
+		if g.Constant {
+			fmt.Println(g.Id+":", repl_value(g.ExprTag), repl_type(g.DataType))
+		}
+	}
+}
+
+// Executes statement after statements of session.
+// Statement is kept in session if program is succeeded.
+// Reports types of variables declared by statement.
+func (s *repl_session) exec_stmt(src string) {
+	s.write_main(src)
+	p := s.parse()
+	if p == nil || build.HasErrors(p.Errors) {
+		if p != nil {
+			s.print_logs(p.Errors)
+		}
+		return
+	}
+	if s.run(src) != jane.EXIT_SUCCESS {
+		return
+	}
+	if len(s.stmts) == 0 {
+		fmt.Println("note: statements are executed again before each input, see :help")
+	}
+	s.stmts = append(s.stmts, src)
+	vars := repl_main_vars(p)
+	for _, v := range vars[s.vars:] {
+		fmt.Println(v.Id+":", repl_type(v.DataType))
+	}
+	s.vars = len(vars)
+}
+
+// Evaluates expression after statements of session.
+// Constant expressions are printed with value computed by parser,
+// others are printed by program. Other inputs are executed as statement.
+func (s *repl_session) eval_expr(src string) {
+	expr := strings.TrimSuffix(strings.TrimSpace(src), lexer.KND_SEMICOLON)
+	if v, ok := s.check_value(lexer.KND_CONST, expr); ok {
+		fmt.Println(repl_value(v.ExprTag), repl_type(v.DataType))
+		return
+	}
+	if v, ok := s.check_value(lexer.KND_LET, expr); ok {
+		if s.run("print("+expr+")") == jane.EXIT_SUCCESS {
+			fmt.Println("", repl_type(v.DataType))
+		}
+		return
+	}
+	// Void expressions are not values, like calls without result.
+	s.exec_stmt(src)
+}
+
+// Declares expression as variable of main function with keyword.
+// Returns variable if session is valid with it.
+func (s *repl_session) check_value(keyword string, expr string) (*ast.Var, bool) {
+	s.write_main(keyword + " " + REPL_VALUE + " = " + expr)
+	p := s.parse()
+	if p == nil || build.HasErrors(p.Errors) {
+		return nil, false
+	}
+	for _, v := range repl_main_vars(p) {
+		if v.Id == REPL_VALUE {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Returns variables declared in top scope of main function of session.
+func repl_main_vars(p *parser.Parser) (vars []*ast.Var) {
+	f, _, _ := p.Defines.FnById(jane.ENTRY_POINT, nil)
+	if f == nil || f.Block == nil {
+		return nil
+	}
+	for i := range f.Block.Tree {
+		if v, ok := f.Block.Tree[i].Data.(ast.Var); ok {
+			vars = append(vars, &v)
+		}
+	}
+	return vars
+}
+
+// Returns constant value as source literal.
+func repl_value(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return lexer.KND_NIL
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func repl_type(t ast.Type) string { return "(" + t.Kind + ")" }
+
+// Parses package of session.
+// Returns nil if package is not parsed.
+func (s *repl_session) parse() (p *parser.Parser) {
+	defer func() {
+		if r := recover(); r != nil {
+			print_error_message(fmt.Sprint("internal compiler error: ", r))
+			p = nil
+		}
+	}()
+	p, err_msg := parser.ParsePackage(s.dir, false)
+	if err_msg != "" {
+		print_error_message(err_msg)
+		return nil
+	}
+	return p
+}
+
+// Writes main function of session.
+// Main function executes statements of session, then given statements.
+func (s *repl_session) write_main(stmts ...string) bool {
+	var src strings.Builder
+	src.WriteString("fn main() {\n")
+	for _, stmt := range s.stmts {
+		src.WriteString(stmt)
+		src.WriteByte('\n')
+	}
+	for _, stmt := range stmts {
+		src.WriteString(stmt)
+		src.WriteByte('\n')
+	}
+	src.WriteString("}\n")
+	return s.write(filepath.Join(s.dir, "main"+jane.EXT), src.String())
+}
+
+func (s *repl_session) write(path string, src string) bool {
+	err := os.WriteFile(path, []byte(src), 0o644)
+	if err != nil {
+		print_error_message(err.Error())
+		return false
+	}
+	return true
+}
+
+// Compiles and executes session with given statement.
+// Returns exit code of run command.
+func (s *repl_session) run(stmt string) int {
+	if !s.write_main("println(\""+REPL_MARKER+"\")", stmt) {
+		return jane.EXIT_SETUP
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		print_error_message(err.Error())
+		return jane.EXIT_SETUP
+	}
+	args := append([]string{cmd_run}, s.args...)
+	command := exec.Command(exe, append(args, s.dir)...)
+	command.Stdin = os.Stdin
+	command.Stdout = &repl_output{w: os.Stdout}
+	command.Stderr = os.Stderr
+	err = command.Run()
+	if err == nil {
+		return jane.EXIT_SUCCESS
+	}
+	if status, ok := program_status(err); ok {
+		return status
+	}
+	print_error_message(err.Error())
+	return jane.EXIT_BACKEND
+}
+
+// Writer that discards output until marker line.
+type repl_output struct {
+	w      io.Writer
+	buf    []byte
+	marked bool
+}
+
+func (o *repl_output) Write(b []byte) (int, error) {
+	if o.marked {
+		return o.w.Write(b)
+	}
+	o.buf = append(o.buf, b...)
+	marker := []byte(REPL_MARKER + "\n")
+	i := bytes.Index(o.buf, marker)
+	if i == -1 {
+		return len(b), nil
+	}
+	o.marked = true
+	rest := o.buf[i+len(marker):]
+	o.buf = nil
+	if len(rest) > 0 {
+		if _, err := o.w.Write(rest); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Prints logs with paths relative to session directory.
+func (s *repl_session) print_logs(logs []build.Log) {
+	for i := range logs {
+		logs[i].Path = s.rel(logs[i].Path)
+		for j := range logs[i].Notes {
+			logs[i].Notes[j].Path = s.rel(logs[i].Notes[j].Path)
+		}
+	}
+	print_log_list_source(logs, func(path string, row int) (string, bool) {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(s.dir, path)
+		}
+		return lexer.SourceLine(path, row)
+	})
+}
+
+// Returns path relative to session directory if possible.
+func (s *repl_session) rel(path string) string {
+	if path == "" {
+		return path
+	}
+	if rel, err := filepath.Rel(s.dir, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// Prints declarations and statements of session.
+func (s *repl_session) print() {
+	for _, decl := range s.decls {
+		fmt.Print(decl)
+	}
+	if len(s.stmts) == 0 {
+		return
+	}
+	fmt.Println("fn main() {")
+	for _, stmt := range s.stmts {
+		fmt.Print(stmt)
+	}
+	fmt.Println("}")
+}
+
+// Removes declarations and statements of session.
+func (s *repl_session) reset() {
+	for i := range s.decls {
+		_ = os.Remove(filepath.Join(s.dir, "decl_"+strconv.Itoa(i+1)+jane.EXT))
+	}
+	s.decls = nil
+	s.stmts = nil
+	s.vars = 0
+}
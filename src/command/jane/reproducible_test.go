@@ -0,0 +1,58 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/DeRuneLabs/jane"
+	"github.com/DeRuneLabs/jane/build"
+	"github.com/DeRuneLabs/jane/command/jane/gen"
+)
+
+// Returns generated code of package at path.
+func gen_code(t *testing.T, path string) string {
+	t.Helper()
+	p := compile(path)
+	if build.HasErrors(p.Errors) {
+		t.Fatalf("compile %s: %s", path, p.Errors[0].Text)
+	}
+	p.WrapPackage()
+	code := gen.Gen(p.Defines, p.Used)
+	append_standard(&code)
+	return code
+}
+
+func TestReproducibleOutput(t *testing.T) {
+	stdlib, err := filepath.Abs(filepath.Join("..", "..", "..", jane.STDLIB))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jane.STDLIB_PATH = stdlib
+	path := filepath.Join("testdata", "reproducible")
+
+	first := gen_code(t, path)
+	second := gen_code(t, path)
+	if first != second {
+		t.Fatal("generated code differs between builds of the same package")
+	}
+}
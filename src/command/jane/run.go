@@ -0,0 +1,113 @@
+// copyright (c) 2024 arfy slowy - derunelabs
+//
+// permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "software"), to deal
+// in the software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the software, and to permit persons to whom the software is
+// furnished to do so, subject to the following conditions:
+//
+// the above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the software.
+//
+// the software is provided "as is", without warranty of any kind, express or
+// implied, including but not limited to the warranties of merchantability,
+// fitness for a particular purpose and noninfringement. in no event shall the
+// authors or copyright holders be liable for any claim, damages or other
+// liability, whether in an action of contract, tort or otherwise, arising from,
+// out of or in connection with the software or the use or other dealings in the
+// software.
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"syscall"
+
+	"github.com/DeRuneLabs/jane"
+)
+
+// Splits run arguments into compiler arguments and program arguments.
+// Program arguments follow the "--" separator.
+func split_run_args(args []string) (compiler_args []string, program_args []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[:i], args[i+1:]
+		}
+	}
+	return args, nil
+}
+
+// Compiles package into temporary directory and executes it.
+// Exits with exit status of program.
+// With --watch, program is compiled and executed again when sources change,
+// program has no stdin then.
+func run() {
+	args, program_args := split_run_args(os.Args[2:])
+	args, watching := take_watch_option(args)
+	path := parse_project_options(args)
+	if mode != mode_compile {
+		exit_err(jane.EXIT_USAGE, "run does not support transpile mode")
+	}
+	if buildmode != buildmode_exe {
+		exit_err(jane.EXIT_USAGE, "run does not support library build modes")
+	}
+
+	if watching {
+		watch(cmd_run, append(append(args, "--"), program_args...), path)
+	}
+
+	tmp, err := os.MkdirTemp("", "jane-run-")
+	if err != nil {
+		exit_err(jane.EXIT_SETUP, err.Error())
+	}
+	cleanup_dirs = append(cleanup_dirs, tmp)
+	cleanup_on_signal()
+	out_dir = tmp
+	out = filepath.Join(tmp, "main")
+	if runtime.GOOS == "windows" {
+		out += ".exe"
+	}
+
+	build_package(path)
+	if print_command {
+		exit(jane.EXIT_SUCCESS)
+	}
+	exit(exec_program(out, program_args))
+}
+
+func exec_program(path string, args []string) int {
+	command := exec.Command(path, args...)
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	err := command.Run()
+	if err == nil {
+		return jane.EXIT_SUCCESS
+	}
+	if status, ok := program_status(err); ok {
+		return status
+	}
+	print_error_message(err.Error())
+	return jane.EXIT_BACKEND
+}
+
+// Returns exit status of program that terminated with error.
+// Programs killed by signal have conventional 128+signal status
+// of shells. Reports false if program could not be run.
+func program_status(err error) (int, bool) {
+	exit_error, ok := err.(*exec.ExitError)
+	if !ok {
+		return 0, false
+	}
+	if ws, ok := exit_error.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return 128 + int(ws.Signal()), true
+	}
+	if exit_error.ExitCode() > 0 {
+		return exit_error.ExitCode(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,258 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/DeRuneLabs/jane"
+	"github.com/DeRuneLabs/jane/ast"
+	"github.com/DeRuneLabs/jane/build"
+	"github.com/DeRuneLabs/jane/parser"
+)
+
+var (
+	testing      = false
+	test_filter  *regexp.Regexp
+	test_verbose = false
+	test_fns     []*ast.Fn
+)
+
+// Result of test run.
+type test_result struct {
+	name    string
+	passed  bool
+	elapsed time.Duration
+	output  string
+}
+
+// Compiles test functions of package and runs each test in own process.
+//
+// Test functions are global functions with the test attribute.
+// With --run, only tests that matches to regular expression are run.
+// With -v, output of passed tests is printed too.
+func test_command() {
+	args := parse_test_options(os.Args[2:])
+	path := parse_project_options(args)
+	if mode != mode_compile {
+		exit_err(jane.EXIT_USAGE, "test does not support transpile mode")
+	}
+	if buildmode != buildmode_exe {
+		exit_err(jane.EXIT_USAGE, "test does not support library build modes")
+	}
+	testing = true
+
+	tmp, err := os.MkdirTemp("", "jane-test-")
+	if err != nil {
+		exit_err(jane.EXIT_SETUP, err.Error())
+	}
+	cleanup_dirs = append(cleanup_dirs, tmp)
+	cleanup_on_signal()
+	out_dir = tmp
+	out = filepath.Join(tmp, "test")
+	if runtime.GOOS == "windows" {
+		out += ".exe"
+	}
+
+	build_package(path)
+	if len(test_fns) == 0 {
+		println("no tests to run")
+		exit(jane.EXIT_SUCCESS)
+	}
+	if print_command {
+		exit(jane.EXIT_SUCCESS)
+	}
+	results := run_tests(out)
+	if print_test_results(results) {
+		exit(jane.EXIT_TEST)
+	}
+	exit(jane.EXIT_SUCCESS)
+}
+
+// Parses test options and returns remaining arguments.
+func parse_test_options(args []string) []string {
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		start := i
+		arg, content := get_option(args, &i)
+		switch arg {
+		case "--run":
+			pattern := get_option_value(args, &i)
+			if pattern == "" {
+				exit_err(jane.EXIT_USAGE, "missing option value: --run")
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				exit_err(jane.EXIT_USAGE, "invalid test pattern: "+err.Error())
+			}
+			test_filter = re
+		case "-v", "--verbose":
+			test_verbose = true
+			if inline_value != nil {
+				exit_err(jane.EXIT_USAGE, "option does not take value: "+arg)
+			}
+		default:
+			if arg == "" {
+				rest = append(rest, content)
+			} else {
+				rest = append(rest, args[start:i+1]...)
+			}
+		}
+	}
+	return rest
+}
+
+// Marks test functions of package as used and collects them.
+func use_tests(p *parser.Parser) {
+	for _, f := range p.Defines.Fns {
+		if !ast.HasAttribute(build.ATTR_TEST, f.Attributes) {
+			continue
+		}
+		if test_filter != nil && !test_filter.MatchString(f.Id) {
+			continue
+		}
+		f.Used = true
+		test_fns = append(test_fns, f)
+	}
+}
+
+// Returns main function of test runner.
+//
+// Runner runs test of name given as first argument, panics of test
+// are caught and reported as failure. Runner lists names of tests
+// if there is no argument.
+func gen_test_main() string {
+	var cpp strings.Builder
+	cpp.WriteString(`
+
+#include <cstring>
+#include <iostream>
+
+struct __jane_test {
+  const char *name;
+  void (*fn)(void);
+};
+
+static const __jane_test __jane_tests[] = {
+`)
+	for _, f := range test_fns {
+		cpp.WriteString("  { \"")
+		cpp.WriteString(f.Id)
+		cpp.WriteString("\", ")
+		cpp.WriteString(f.OutId())
+		cpp.WriteString(" },\n")
+	}
+	cpp.WriteString(`};
+
+int main(int argc, char *argv[]) {
+  std::set_terminate( &__jane_terminate_handler );
+  __jane_set_sig_handler(__jane_signal_handler);
+  __jane_setup_command_line_args(argc, argv);
+  __jane_call_package_initializers();
+  if (argc < 2) {
+    for (const __jane_test &test : __jane_tests) {
+      std::cout << test.name << std::endl;
+    }
+    return(EXIT_SUCCESS);
+  }
+  for (const __jane_test &test : __jane_tests) {
+    if (std::strcmp(test.name, argv[1]) != 0) {
+      continue;
+    }
+    try {
+      test.fn();
+    } catch (const jane::Exception &e) {
+      std::cerr << "panic: " << e.what() << std::endl;
+      return(EXIT_FAILURE);
+    } catch (const std::exception &e) {
+      std::cerr << "panic: " << e.what() << std::endl;
+      return(EXIT_FAILURE);
+    } catch (...) {
+      std::cerr << "panic: unknown exception" << std::endl;
+      return(EXIT_FAILURE);
+    }
+    return(EXIT_SUCCESS);
+  }
+  std::cerr << "undefined test: " << argv[1] << std::endl;
+  return(EXIT_FAILURE);
+}`)
+	return cpp.String()
+}
+
+// Runs each test in own process, so crashes of tests are isolated.
+func run_tests(path string) []test_result {
+	results := make([]test_result, 0, len(test_fns))
+	for _, f := range test_fns {
+		if test_verbose {
+			fmt.Println("=== RUN   " + f.Id)
+		}
+		command := exec.Command(path, f.Id)
+		start := time.Now()
+		output, err := command.CombinedOutput()
+		result := test_result{
+			name:    f.Id,
+			passed:  err == nil,
+			elapsed: time.Since(start),
+			output:  string(output),
+		}
+		if err != nil {
+			if _, ok := err.(*exec.ExitError); !ok {
+				result.output += err.Error() + "\n"
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// Prints results of tests, returns true if any test failed.
+func print_test_results(results []test_result) bool {
+	failed := 0
+	var total time.Duration
+	for _, r := range results {
+		total += r.elapsed
+		status := "PASS"
+		if !r.passed {
+			status = "FAIL"
+			failed++
+		}
+		fmt.Printf("--- %s: %s (%.2fs)\n", status, r.name, r.elapsed.Seconds())
+		if r.output != "" && (!r.passed || test_verbose) {
+			for _, line := range strings.Split(strings.TrimRight(r.output, "\n"), "\n") {
+				fmt.Println("    " + line)
+			}
+		}
+	}
+	status := "PASS"
+	if failed > 0 {
+		status = "FAIL"
+	}
+	fmt.Printf("%s: %d passed, %d failed (%.2fs)\n",
+		status, len(results)-failed, failed, total.Seconds())
+	return failed > 0
+}
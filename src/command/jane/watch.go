@@ -0,0 +1,174 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/DeRuneLabs/jane"
+	"github.com/DeRuneLabs/jane/manifest"
+	"github.com/DeRuneLabs/jane/parser"
+)
+
+const (
+	WATCH_POLL     = 500 * time.Millisecond
+	WATCH_DEBOUNCE = 200 * time.Millisecond
+	// Time to wait for child to exit before killing it.
+	WATCH_KILL_TIMEOUT = 2 * time.Second
+)
+
+// Removes watch option from args.
+// Reports whether watch option is given.
+func take_watch_option(args []string) ([]string, bool) {
+	rest := make([]string, 0, len(args))
+	watching := false
+	for _, arg := range args {
+		if arg == "--watch" {
+			watching = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, watching
+}
+
+// Returns sources to watch for package of path.
+// Sources are package itself, used packages and used C++ headers.
+// Manifest and lock file of project are watched too,
+// they change options and library roots of command.
+func watch_deps(path string) (deps []cache_dep) {
+	abs, _ := filepath.Abs(path)
+	defer func() {
+		// Package may be broken while it is being edited,
+		// package itself is watched at least.
+		if recover() != nil || len(deps) == 0 {
+			deps = []cache_dep{{Path: abs, IsDir: true}}
+		}
+		if m := manifest.Find(jane.WORKING_PATH); m != "" {
+			deps = append(deps,
+				cache_dep{Path: m},
+				cache_dep{Path: filepath.Join(filepath.Dir(m), manifest.LOCK_FILE_NAME)})
+		}
+	}()
+	p, err_msg := parser.ParsePackage(path, true)
+	if err_msg != "" {
+		return nil
+	}
+	return get_deps(path, p)
+}
+
+// Returns stamp of sources that changes if any source changes.
+func watch_stamp(deps []cache_dep) string {
+	hashes := make([]string, 0, len(deps)*2)
+	for _, d := range deps {
+		hash, _ := hash_dep(d.Path, d.IsDir)
+		hashes = append(hashes, d.Path, hash)
+	}
+	return hash_strings(hashes...)
+}
+
+// Waits until sources stop changing and returns final stamp.
+func watch_debounce(deps []cache_dep, stamp string) string {
+	for {
+		time.Sleep(WATCH_DEBOUNCE)
+		next := watch_stamp(deps)
+		if next == stamp {
+			return stamp
+		}
+		stamp = next
+	}
+}
+
+// Stops child process and its children, waits for exit of child.
+func stop_watch_child(command *exec.Cmd, done <-chan error) {
+	_ = interrupt_process_tree(command.Process)
+	select {
+	case <-done:
+	case <-time.After(WATCH_KILL_TIMEOUT):
+		_ = kill_process_tree(command.Process)
+		<-done
+	}
+}
+
+// Runs command of args in child process again whenever sources of
+// package of path change. Child that is still running when sources
+// change is stopped, so long-running programs are restarted too.
+// Output of child is not buffered, diagnostics printed as reported.
+//
+// Child runs in own process group to be stopped with its children.
+// Group is not foreground group of terminal, reading terminal would
+// stop child with SIGTTIN, so child has no stdin.
+func watch(cmd string, args []string, path string) {
+	exe, err := os.Executable()
+	if err != nil {
+		exit_err(jane.EXIT_SETUP, err.Error())
+	}
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	for {
+		deps := watch_deps(path)
+		stamp := watch_stamp(deps)
+		command := exec.Command(exe, append([]string{cmd}, args...)...)
+		command.Stdout = os.Stdout
+		command.Stderr = os.Stderr
+		new_process_group(command)
+		if err := command.Start(); err != nil {
+			exit_err(jane.EXIT_SETUP, err.Error())
+		}
+		done := make(chan error, 1)
+		go func() { done <- command.Wait() }()
+		running := true
+		ticker := time.NewTicker(WATCH_POLL)
+	wait:
+		for {
+			select {
+			case <-interrupt:
+				if running {
+					stop_watch_child(command, done)
+				}
+				exit(jane.EXIT_INTERRUPT)
+			case <-done:
+				running = false
+				code := command.ProcessState.ExitCode()
+				if code != jane.EXIT_SUCCESS {
+					println("[watch] exited with status " + strconv.Itoa(code))
+				}
+				println("[watch] waiting for changes")
+			case <-ticker.C:
+				if watch_stamp(deps) != stamp {
+					break wait
+				}
+			}
+		}
+		ticker.Stop()
+		watch_debounce(deps, stamp)
+		if running {
+			stop_watch_child(command, done)
+		}
+		println("[watch] sources changed, rebuilding")
+	}
+}
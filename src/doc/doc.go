@@ -0,0 +1,601 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package doc
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/DeRuneLabs/jane/ast"
+	"github.com/DeRuneLabs/jane/lexer"
+)
+
+// Kinds of documented symbols.
+const (
+	KIND_CONST  = "const"
+	KIND_VAR    = "var"
+	KIND_TYPE   = "type"
+	KIND_ENUM   = "enum"
+	KIND_TRAIT  = "trait"
+	KIND_STRUCT = "struct"
+	KIND_FN     = "fn"
+	KIND_FIELD  = "field"
+	KIND_ITEM   = "item"
+)
+
+// Reference to documented symbol from type in signature.
+// Offset is byte offset of identifier in signature.
+type Ref struct {
+	Id      string `json:"id"`
+	Package string `json:"package"`
+	Anchor  string `json:"anchor"`
+	Offset  int    `json:"offset"`
+}
+
+type Symbol struct {
+	Kind      string    `json:"kind"`
+	Name      string    `json:"name"`
+	Anchor    string    `json:"anchor"`
+	Signature string    `json:"signature"`
+	Doc       string    `json:"doc,omitempty"`
+	File      string    `json:"file,omitempty"`
+	Row       int       `json:"row,omitempty"`
+	Refs      []Ref     `json:"refs,omitempty"`
+	Members   []*Symbol `json:"members,omitempty"`
+
+	types    []type_span // Types written in signature.
+	generics []string
+}
+
+// Type written in signature at offset.
+type type_span struct {
+	offset int
+	kind   string
+}
+
+// Builder of signature, records positions of written types.
+type sig_builder struct {
+	strings.Builder
+	types []type_span
+}
+
+func (b *sig_builder) write_type(kind string) {
+	b.types = append(b.types, type_span{offset: b.Len(), kind: kind})
+	b.WriteString(kind)
+}
+
+type Package struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Symbols []*Symbol `json:"symbols"`
+
+	uses []*ast.UseDecl
+}
+
+// Documentation of packages with cross references.
+type Set struct {
+	Packages []*Package `json:"packages"`
+}
+
+// Order of symbol kinds in documentation.
+var KIND_ORDER = [...]string{
+	KIND_CONST,
+	KIND_VAR,
+	KIND_TYPE,
+	KIND_ENUM,
+	KIND_TRAIT,
+	KIND_STRUCT,
+	KIND_FN,
+}
+
+func kind_index(kind string) int {
+	for i, k := range KIND_ORDER {
+		if k == kind {
+			return i
+		}
+	}
+	return len(KIND_ORDER)
+}
+
+// Returns documentation of public defines of package.
+// Uses are use declarations of package, types of signatures
+// are linked only to package and packages used by it.
+func New(name string, path string, dm *ast.Defmap, uses []*ast.UseDecl) *Package {
+	pkg := &Package{Name: name, Path: path, uses: uses}
+	for _, g := range dm.Globals {
+		if g.Public && is_source_define(g.Token) {
+			pkg.Symbols = append(pkg.Symbols, global_symbol(g))
+		}
+	}
+	for _, t := range dm.Types {
+		if t.Pub && is_source_define(t.Token) {
+			pkg.Symbols = append(pkg.Symbols, type_symbol(t))
+		}
+	}
+	for _, e := range dm.Enums {
+		if e.Pub && is_source_define(e.Token) {
+			pkg.Symbols = append(pkg.Symbols, enum_symbol(e))
+		}
+	}
+	for _, t := range dm.Traits {
+		if t.Pub && is_source_define(t.Token) {
+			pkg.Symbols = append(pkg.Symbols, trait_symbol(t))
+		}
+	}
+	for _, s := range dm.Structs {
+		if s.Pub && is_source_define(s.Token) {
+			pkg.Symbols = append(pkg.Symbols, struct_symbol(s))
+		}
+	}
+	for _, f := range dm.Fns {
+		if f.Public && is_source_define(f.Token) {
+			pkg.Symbols = append(pkg.Symbols, fn_symbol(f, ""))
+		}
+	}
+	sort.SliceStable(pkg.Symbols, func(i, j int) bool {
+		a, b := pkg.Symbols[i], pkg.Symbols[j]
+		if a.Kind != b.Kind {
+			return kind_index(a.Kind) < kind_index(b.Kind)
+		}
+		return a.Name < b.Name
+	})
+	return pkg
+}
+
+// Reports whether define is written in source file.
+// Builtin defines have not any source.
+func is_source_define(t lexer.Token) bool {
+	return t.File != nil
+}
+
+func make_symbol(kind string, name string, t lexer.Token, doc string) *Symbol {
+	s := &Symbol{
+		Kind:   kind,
+		Name:   name,
+		Anchor: name,
+		Doc:    strings.TrimSpace(doc),
+		Row:    t.Row,
+	}
+	if t.File != nil {
+		s.File = filepath.Base(t.File.Path())
+	}
+	return s
+}
+
+func global_symbol(g *ast.Var) *Symbol {
+	kind := KIND_VAR
+	if g.Constant {
+		kind = KIND_CONST
+	}
+	s := make_symbol(kind, g.Id, g.Token, g.Doc)
+	var sig sig_builder
+	sig.WriteString(pub_prefix(g.Public))
+	if g.Constant {
+		sig.WriteString(lexer.KND_CONST)
+	} else {
+		sig.WriteString(lexer.KND_LET)
+		if g.Mutable {
+			sig.WriteString(" " + lexer.KND_MUT)
+		}
+	}
+	sig.WriteString(" " + g.Id)
+	if g.DataType.Kind != "" {
+		sig.WriteString(": ")
+		sig.write_type(g.DataType.Kind)
+	}
+	if expr := tokens_string(g.Expr.Tokens); expr != "" {
+		sig.WriteString(" = " + expr)
+	}
+	s.Signature, s.types = sig.String(), sig.types
+	return s
+}
+
+func type_symbol(t *ast.TypeAlias) *Symbol {
+	s := make_symbol(KIND_TYPE, t.Id, t.Token, t.Doc)
+	var sig sig_builder
+	sig.WriteString(pub_prefix(t.Pub) + "type " + t.Id + ": ")
+	sig.write_type(t.TargetType.Kind)
+	s.Signature, s.types = sig.String(), sig.types
+	return s
+}
+
+func enum_symbol(e *ast.Enum) *Symbol {
+	s := make_symbol(KIND_ENUM, e.Id, e.Token, e.Doc)
+	var sig sig_builder
+	sig.WriteString(pub_prefix(e.Pub) + "enum " + e.Id)
+	if e.DataType.Kind != "" {
+		sig.WriteString(": ")
+		sig.write_type(e.DataType.Kind)
+	}
+	s.Signature, s.types = sig.String(), sig.types
+	for _, item := range e.Items {
+		m := &Symbol{
+			Kind:      KIND_ITEM,
+			Name:      item.Id,
+			Anchor:    e.Id + "." + item.Id,
+			Signature: item.Id,
+			Row:       item.Token.Row,
+		}
+		if expr := tokens_string(item.Expr.Tokens); expr != "" {
+			m.Signature += " = " + expr
+		}
+		s.Members = append(s.Members, m)
+	}
+	return s
+}
+
+func trait_symbol(t *ast.Trait) *Symbol {
+	s := make_symbol(KIND_TRAIT, t.Id, t.Token, t.Desc)
+	s.Signature = pub_prefix(t.Pub) + "trait " + t.Id
+	for _, f := range t.Funcs {
+		s.Members = append(s.Members, fn_symbol(f, t.Id))
+	}
+	return s
+}
+
+func struct_symbol(st *ast.Struct) *Symbol {
+	s := make_symbol(KIND_STRUCT, st.Id, st.Token, st.Doc)
+	s.Signature = pub_prefix(st.Pub) + "struct " + st.Id + generics_string(st.Generics)
+	s.generics = generic_ids(st.Generics)
+	for _, f := range st.Fields {
+		if !f.Public {
+			continue
+		}
+		sig := field_signature(f)
+		s.Members = append(s.Members, &Symbol{
+			Kind:      KIND_FIELD,
+			Name:      f.Id,
+			Anchor:    st.Id + "." + f.Id,
+			Signature: sig.String(),
+			Doc:       strings.TrimSpace(f.Doc),
+			Row:       f.Token.Row,
+			types:     sig.types,
+		})
+	}
+	if st.Defines != nil {
+		for _, f := range st.Defines.Fns {
+			if f.Public {
+				s.Members = append(s.Members, fn_symbol(f, st.Id))
+			}
+		}
+	}
+	return s
+}
+
+// Returns symbol of function.
+// Owner is the struct or trait of method, empty for functions.
+func fn_symbol(f *ast.Fn, owner string) *Symbol {
+	s := make_symbol(KIND_FN, f.Id, f.Token, f.Doc)
+	if owner != "" {
+		s.Anchor = owner + "." + f.Id
+	}
+	sig := fn_signature(f)
+	s.Signature, s.types = sig.String(), sig.types
+	s.generics = generic_ids(f.Generics)
+	return s
+}
+
+func pub_prefix(pub bool) string {
+	if pub {
+		return lexer.KND_PUB + " "
+	}
+	return ""
+}
+
+func field_signature(f *ast.Var) *sig_builder {
+	s := new(sig_builder)
+	s.WriteString(pub_prefix(f.Public))
+	if f.Mutable {
+		s.WriteString(lexer.KND_MUT + " ")
+	}
+	s.WriteString(f.Id)
+	s.WriteString(": ")
+	s.write_type(f.DataType.Kind)
+	return s
+}
+
+// Returns signature of define in Jane syntax.
+// Struct fields are given as variables with IsField.
+func Signature(def any) string {
+	switch t := def.(type) {
+	case *ast.Fn:
+		return fn_signature(t).String()
+	case *ast.Struct:
+		return pub_prefix(t.Pub) + "struct " + t.Id + generics_string(t.Generics)
+	case *ast.Trait:
+		return pub_prefix(t.Pub) + "trait " + t.Id
+	case *ast.TypeAlias:
+		return pub_prefix(t.Pub) + "type " + t.Id + ": " + t.TargetType.Kind
+	case *ast.Enum:
+		return enum_symbol(t).Signature
+	case *ast.Var:
+		if t.IsField {
+			return field_signature(t).String()
+		}
+		return global_symbol(t).Signature
+	}
+	return ""
+}
+
+func generic_ids(generics []*ast.GenericType) []string {
+	ids := make([]string, len(generics))
+	for i, g := range generics {
+		ids[i] = g.Id
+	}
+	return ids
+}
+
+func generics_string(generics []*ast.GenericType) string {
+	if len(generics) == 0 {
+		return ""
+	}
+	return "[" + strings.Join(generic_ids(generics), ", ") + "]"
+}
+
+func write_param(s *sig_builder, p ast.Param) {
+	if p.Mutable {
+		s.WriteString(lexer.KND_MUT + " ")
+	}
+	if p.Id != "" && p.Id != lexer.ANONYMOUS_ID {
+		s.WriteString(p.Id)
+		s.WriteString(": ")
+	}
+	if p.Variadic {
+		s.WriteString(lexer.KND_TRIPLE_DOT)
+	}
+	s.write_type(p.DataType.Kind)
+}
+
+func write_ret(s *sig_builder, rt ast.RetType) {
+	named := func(i int, kind string) {
+		if i < len(rt.Identifiers) && !lexer.IsIgnoreId(rt.Identifiers[i].Kind) {
+			s.WriteString(rt.Identifiers[i].Kind + ": ")
+		}
+		s.write_type(kind)
+	}
+	if rt.DataType.MultiTyped {
+		s.WriteByte('(')
+		for i, t := range rt.DataType.Tag.([]ast.Type) {
+			if i > 0 {
+				s.WriteString(", ")
+			}
+			named(i, t.Kind)
+		}
+		s.WriteByte(')')
+		return
+	}
+	if len(rt.Identifiers) == 1 && !lexer.IsIgnoreId(rt.Identifiers[0].Kind) {
+		s.WriteByte('(')
+		named(0, rt.DataType.Kind)
+		s.WriteByte(')')
+		return
+	}
+	s.write_type(rt.DataType.Kind)
+}
+
+func fn_signature(f *ast.Fn) *sig_builder {
+	s := new(sig_builder)
+	if f.Public {
+		s.WriteString("pub ")
+	}
+	if f.IsUnsafe {
+		s.WriteString("unsafe ")
+	}
+	s.WriteString("fn ")
+	s.WriteString(f.Id)
+	s.WriteString(generics_string(f.Generics))
+	s.WriteByte('(')
+	if f.Receiver != nil {
+		s.WriteString(f.Receiver.ReceiverTypeString())
+	}
+	for i, p := range f.Params {
+		if i > 0 || f.Receiver != nil {
+			s.WriteString(", ")
+		}
+		write_param(s, p)
+	}
+	s.WriteByte(')')
+	if rt := f.RetType; rt.DataType.MultiTyped || rt.DataType.Kind != "" {
+		s.WriteString(": ")
+		write_ret(s, rt)
+	}
+	return s
+}
+
+// Returns source form of tokens, spacing between tokens is kept.
+func tokens_string(toks []lexer.Token) string {
+	var s strings.Builder
+	for i, t := range toks {
+		if i > 0 {
+			prev := toks[i-1]
+			if t.Row != prev.EndRow() || t.Column > prev.EndColumn() {
+				s.WriteByte(' ')
+			}
+		}
+		s.WriteString(t.Kind)
+	}
+	return s.String()
+}
+
+// Returns file name of package documentation without extension.
+func (p *Package) FileName() string {
+	return strings.ReplaceAll(p.Name, lexer.KND_DBLCOLON, ".")
+}
+
+func (p *Package) find(name string) *Symbol {
+	for _, s := range p.Symbols {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Returns documented package of path.
+func (set *Set) package_of(path string) *Package {
+	for _, pkg := range set.Packages {
+		if pkg.Path == path {
+			return pkg
+		}
+	}
+	return nil
+}
+
+// Reports whether identifier is imported by use declaration.
+func imports_id(u *ast.UseDecl, id string) bool {
+	if u.FullUse {
+		return true
+	}
+	for _, s := range u.Selectors {
+		if s.Kind == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns documented package that defines identifier.
+// Identifier is resolved in package and packages used by it,
+// qualified identifier must be qualified with namespace of use.
+func (set *Set) resolve(from *Package, id string) (*Package, *Symbol) {
+	ns, name := "", id
+	i := strings.LastIndex(id, lexer.KND_DBLCOLON)
+	if i != -1 {
+		ns, name = id[:i], id[i+len(lexer.KND_DBLCOLON):]
+	} else if s := from.find(id); s != nil {
+		return from, s
+	}
+	for _, u := range from.uses {
+		if u.Cpp {
+			continue
+		}
+		if ns != "" && u.LinkString != ns {
+			continue
+		}
+		if ns == "" && !imports_id(u, name) {
+			continue
+		}
+		pkg := set.package_of(u.Path)
+		if pkg == nil {
+			continue
+		}
+		if s := pkg.find(name); s != nil {
+			return pkg, s
+		}
+	}
+	return nil, nil
+}
+
+func is_id_rune(b byte, first bool) bool {
+	return b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') ||
+		(!first && b >= '0' && b <= '9')
+}
+
+// Identifier of type at offset.
+type type_id struct {
+	offset int
+	id     string
+}
+
+// Returns identifiers of type, qualified identifiers are not split.
+// Parameter names of function types are skipped.
+func type_ids(kind string) []type_id {
+	var ids []type_id
+	for i := 0; i < len(kind); {
+		if !is_id_rune(kind[i], true) {
+			i++
+			continue
+		}
+		start := i
+		for i < len(kind) {
+			for i < len(kind) && is_id_rune(kind[i], false) {
+				i++
+			}
+			if !strings.HasPrefix(kind[i:], lexer.KND_DBLCOLON) {
+				break
+			}
+			i += len(lexer.KND_DBLCOLON)
+		}
+		if strings.HasPrefix(kind[i:], lexer.KND_COLON) && is_param_name(kind, start) {
+			continue
+		}
+		ids = append(ids, type_id{offset: start, id: kind[start:i]})
+	}
+	return ids
+}
+
+// Reports whether identifier at start of type followed by colon
+// is parameter name of function type, not key type of map.
+func is_param_name(kind string, start int) bool {
+	prev := strings.TrimRight(kind[:start], " ")
+	prev = strings.TrimSuffix(prev, lexer.KND_MUT)
+	prev = strings.TrimRight(prev, " ")
+	return strings.HasSuffix(prev, lexer.KND_LPAREN) || strings.HasSuffix(prev, lexer.KND_COMMA)
+}
+
+// Links types of symbol and its members.
+// Generics are generic type identifiers in scope of symbol.
+func (set *Set) link_symbol(from *Package, s *Symbol, generics []string) {
+	s.Refs = nil
+	generics = append(generics[:len(generics):len(generics)], s.generics...)
+	for _, t := range s.types {
+		for _, id := range type_ids(t.kind) {
+			if slices.Contains(generics, id.id) {
+				continue
+			}
+			pkg, target := set.resolve(from, id.id)
+			if target == nil {
+				continue
+			}
+			s.Refs = append(s.Refs, Ref{
+				Id:      id.id,
+				Package: pkg.Name,
+				Anchor:  target.Anchor,
+				Offset:  t.offset + id.offset,
+			})
+		}
+	}
+	for _, m := range s.Members {
+		set.link_symbol(from, m, generics)
+	}
+}
+
+// Resolves cross references of all symbols.
+func (set *Set) Link() {
+	for _, pkg := range set.Packages {
+		for _, s := range pkg.Symbols {
+			set.link_symbol(pkg, s, nil)
+		}
+	}
+}
+
+// Returns documentation set as JSON.
+func (set *Set) JSON() string {
+	var sb strings.Builder
+	encoder := json.NewEncoder(&sb)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(set)
+	return sb.String()
+}
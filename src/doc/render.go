@@ -0,0 +1,215 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package doc
+
+import (
+	"html"
+	"strings"
+
+	"github.com/DeRuneLabs/jane/lexer"
+)
+
+const (
+	EXT_HTML     = ".html"
+	EXT_MARKDOWN = ".md"
+	EXT_JSON     = ".json"
+)
+
+// Name of index file of documentation set, without extension.
+const INDEX_NAME = "index"
+
+var SECTION_TITLES = map[string]string{
+	KIND_CONST:  "Constants",
+	KIND_VAR:    "Variables",
+	KIND_TYPE:   "Type Aliases",
+	KIND_ENUM:   "Enums",
+	KIND_TRAIT:  "Traits",
+	KIND_STRUCT: "Structs",
+	KIND_FN:     "Functions",
+}
+
+const HTML_STYLE = `body{font-family:sans-serif;max-width:60em;margin:auto;padding:1em}
+pre{background:#f4f4f4;padding:.5em;overflow-x:auto}
+.member{margin-left:2em}`
+
+func (r Ref) href(ext string) string {
+	return strings.ReplaceAll(r.Package, lexer.KND_DBLCOLON, ".") + ext + "#" + r.Anchor
+}
+
+// Returns HTML of signature, referenced identifiers are linked.
+func signature_html(s *Symbol, ext string) string {
+	refs := make(map[int]Ref, len(s.Refs))
+	for _, r := range s.Refs {
+		refs[r.Offset] = r
+	}
+	sig := s.Signature
+	var sb strings.Builder
+	for i := 0; i < len(sig); {
+		if !is_id_rune(sig[i], true) {
+			sb.WriteString(html.EscapeString(sig[i : i+1]))
+			i++
+			continue
+		}
+		start := i
+		for i < len(sig) {
+			for i < len(sig) && is_id_rune(sig[i], false) {
+				i++
+			}
+			if !strings.HasPrefix(sig[i:], lexer.KND_DBLCOLON) {
+				break
+			}
+			i += len(lexer.KND_DBLCOLON)
+		}
+		id := sig[start:i]
+		if r, ok := refs[start]; ok {
+			sb.WriteString(`<a href="` + html.EscapeString(r.href(ext)) + `">`)
+			sb.WriteString(html.EscapeString(id))
+			sb.WriteString("</a>")
+		} else {
+			sb.WriteString(html.EscapeString(id))
+		}
+	}
+	return "<pre><code>" + sb.String() + "</code></pre>\n"
+}
+
+// Returns symbols of package grouped by kind in documentation order.
+func (p *Package) sections() [][]*Symbol {
+	var sections [][]*Symbol
+	for _, kind := range KIND_ORDER {
+		var section []*Symbol
+		for _, s := range p.Symbols {
+			if s.Kind == kind {
+				section = append(section, s)
+			}
+		}
+		if len(section) > 0 {
+			sections = append(sections, section)
+		}
+	}
+	return sections
+}
+
+func doc_html(doc string) string {
+	var sb strings.Builder
+	for _, paragraph := range strings.Split(doc, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph != "" {
+			sb.WriteString("<p>" + html.EscapeString(paragraph) + "</p>\n")
+		}
+	}
+	return sb.String()
+}
+
+func symbol_html(sb *strings.Builder, s *Symbol, member bool) {
+	if member {
+		sb.WriteString(`<div class="member">` + "\n")
+		sb.WriteString(`<h4 id="` + html.EscapeString(s.Anchor) + `">` + html.EscapeString(s.Name) + "</h4>\n")
+	} else {
+		sb.WriteString(`<h3 id="` + html.EscapeString(s.Anchor) + `">` + html.EscapeString(s.Name) + "</h3>\n")
+	}
+	sb.WriteString(signature_html(s, EXT_HTML))
+	sb.WriteString(doc_html(s.Doc))
+	for _, m := range s.Members {
+		symbol_html(sb, m, true)
+	}
+	if member {
+		sb.WriteString("</div>\n")
+	}
+}
+
+func html_page(title string, body string) string {
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	sb.WriteString("<title>" + html.EscapeString(title) + "</title>\n")
+	sb.WriteString("<style>\n" + HTML_STYLE + "\n</style>\n")
+	sb.WriteString("</head>\n<body>\n")
+	sb.WriteString(body)
+	sb.WriteString("</body>\n</html>\n")
+	return sb.String()
+}
+
+// Returns HTML documentation page of package.
+func (p *Package) HTML() string {
+	var sb strings.Builder
+	sb.WriteString(`<p><a href="` + INDEX_NAME + EXT_HTML + `">Index</a></p>` + "\n")
+	sb.WriteString("<h1>Package " + html.EscapeString(p.Name) + "</h1>\n")
+	for _, section := range p.sections() {
+		sb.WriteString("<h2>" + SECTION_TITLES[section[0].Kind] + "</h2>\n")
+		for _, s := range section {
+			symbol_html(&sb, s, false)
+		}
+	}
+	return html_page(p.Name, sb.String())
+}
+
+// Returns HTML index page of documented packages.
+func (set *Set) IndexHTML() string {
+	var sb strings.Builder
+	sb.WriteString("<h1>Packages</h1>\n<ul>\n")
+	for _, p := range set.Packages {
+		href := html.EscapeString(p.FileName() + EXT_HTML)
+		sb.WriteString(`<li><a href="` + href + `">` + html.EscapeString(p.Name) + "</a></li>\n")
+	}
+	sb.WriteString("</ul>\n")
+	return html_page("Packages", sb.String())
+}
+
+func symbol_markdown(sb *strings.Builder, s *Symbol, member bool) {
+	sb.WriteString(`<a id="` + html.EscapeString(s.Anchor) + `"></a>` + "\n\n")
+	if member {
+		sb.WriteString("#### " + s.Anchor + "\n\n")
+	} else {
+		sb.WriteString("### " + s.Name + "\n\n")
+	}
+	sb.WriteString(signature_html(s, EXT_MARKDOWN))
+	sb.WriteByte('\n')
+	if s.Doc != "" {
+		sb.WriteString(s.Doc)
+		sb.WriteString("\n\n")
+	}
+	for _, m := range s.Members {
+		symbol_markdown(sb, m, true)
+	}
+}
+
+// Returns Markdown documentation page of package.
+func (p *Package) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString("[Index](" + INDEX_NAME + EXT_MARKDOWN + ")\n\n")
+	sb.WriteString("# Package " + p.Name + "\n\n")
+	for _, section := range p.sections() {
+		sb.WriteString("## " + SECTION_TITLES[section[0].Kind] + "\n\n")
+		for _, s := range section {
+			symbol_markdown(&sb, s, false)
+		}
+	}
+	return sb.String()
+}
+
+// Returns Markdown index page of documented packages.
+func (set *Set) IndexMarkdown() string {
+	var sb strings.Builder
+	sb.WriteString("# Packages\n\n")
+	for _, p := range set.Packages {
+		sb.WriteString("- [" + p.Name + "](" + p.FileName() + EXT_MARKDOWN + ")\n")
+	}
+	return sb.String()
+}
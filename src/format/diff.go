@@ -0,0 +1,142 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package format
+
+import (
+	"fmt"
+	"strings"
+)
+
+const DIFF_CONTEXT = 3
+
+type edit struct {
+	op   byte
+	text string
+}
+
+func split_lines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Returns line edits of a to b by longest common subsequence.
+func diff_lines(a, b []string) []edit {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var edits []edit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, edit{' ', a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{'-', a[i]})
+			i++
+		default:
+			edits = append(edits, edit{'+', b[j]})
+			j++
+		}
+	}
+	return edits
+}
+
+// Returns unified diff of old and new content of file.
+// Returns empty string if contents are same.
+func Diff(path string, old, new []byte) string {
+	edits := diff_lines(split_lines(string(old)), split_lines(string(new)))
+	var sb strings.Builder
+	// Line numbers at start of each edit.
+	old_line, new_line := make([]int, len(edits)+1), make([]int, len(edits)+1)
+	old_line[0], new_line[0] = 1, 1
+	for i, e := range edits {
+		old_line[i+1], new_line[i+1] = old_line[i], new_line[i]
+		if e.op != '+' {
+			old_line[i+1]++
+		}
+		if e.op != '-' {
+			new_line[i+1]++
+		}
+	}
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			i++
+			continue
+		}
+		start := i - DIFF_CONTEXT
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(edits) {
+			if edits[end].op != ' ' {
+				end++
+				continue
+			}
+			k := end
+			for k < len(edits) && edits[k].op == ' ' && k-end < 2*DIFF_CONTEXT {
+				k++
+			}
+			if k == len(edits) || edits[k].op == ' ' {
+				break
+			}
+			end = k
+		}
+		stop := end + DIFF_CONTEXT
+		if stop > len(edits) {
+			stop = len(edits)
+		}
+		if sb.Len() == 0 {
+			sb.WriteString("--- " + path + "\n")
+			sb.WriteString("+++ " + path + "\n")
+		}
+		sb.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n",
+			old_line[start], old_line[stop]-old_line[start],
+			new_line[start], new_line[stop]-new_line[start]))
+		for _, e := range edits[start:stop] {
+			sb.WriteByte(e.op)
+			sb.WriteString(e.text)
+			if !strings.HasSuffix(e.text, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = stop
+	}
+	return sb.String()
+}
@@ -0,0 +1,587 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package format
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/DeRuneLabs/jane/build"
+	"github.com/DeRuneLabs/jane/lexer"
+)
+
+const INDENT = "  "
+
+type scope struct {
+	kind    string
+	indent  int
+	match   bool
+	in_case bool
+	prefix  bool
+}
+
+type line struct {
+	toks     []lexer.Token
+	row      int
+	end_row  int
+	indent   int
+	is_case  bool
+	code     string
+	comment  string
+	pad      int
+	eq_pos   int
+	body_pos int
+}
+
+type formatter struct {
+	lines  []*line
+	scopes []scope
+	unary  []bool
+	spaced []bool
+	prefix []bool
+	cur    *line
+	cont   bool
+}
+
+// Returns canonical formatted source of file.
+// Source is not formatted if it has lexical errors,
+// or if formatting changes token sequence of source.
+func Format(path string, src []byte) ([]byte, []build.Log) {
+	toks, logs := lex(path, src)
+	if len(logs) > 0 {
+		return nil, logs
+	}
+	f := new(formatter)
+	f.split_lines(toks)
+	for _, ln := range f.lines {
+		f.format_line(ln)
+	}
+	f.fix_comment_indents()
+	f.align_defs()
+	f.align_cases()
+	f.align_comments()
+	out := f.write()
+	new_toks, logs := lex(path, out)
+	if len(logs) > 0 {
+		return nil, logs
+	}
+	if log, ok := compare_tokens(path, toks, new_toks); !ok {
+		return nil, []build.Log{log}
+	}
+	return out, nil
+}
+
+func lex(path string, src []byte) ([]lexer.Token, []build.Log) {
+	l := lexer.New(lexer.NewFile(path))
+	l.KeepComments = true
+	toks := l.LexData(src)
+	return toks, l.Logs
+}
+
+func comment_kind(t lexer.Token) string {
+	if strings.HasPrefix(t.Kind, lexer.KND_LN_COMMENT) {
+		return strings.TrimRight(t.Kind, " \t\r\v")
+	}
+	return t.Kind
+}
+
+func token_kind(t lexer.Token) string {
+	if t.Id == lexer.ID_COMMENT {
+		return comment_kind(t)
+	}
+	return t.Kind
+}
+
+func compare_tokens(path string, old, new []lexer.Token) (build.Log, bool) {
+	n := len(old)
+	if len(new) < n {
+		n = len(new)
+	}
+	for i := 0; i < n; i++ {
+		if old[i].Id != new[i].Id || token_kind(old[i]) != token_kind(new[i]) {
+			t := old[i]
+			return build.Err(t.Row, t.Column, t.EndRow(), t.EndColumn(), path, "format_changes_tokens"), false
+		}
+	}
+	if len(old) != len(new) {
+		return build.Err(0, 0, 0, 0, path, "format_changes_tokens"), false
+	}
+	return build.Log{}, true
+}
+
+// Reports whether there is whitespace between tokens in source.
+func gap(a, b lexer.Token) bool {
+	return b.Row != a.EndRow() || b.Column > a.EndColumn()
+}
+
+func (f *formatter) split_lines(toks []lexer.Token) {
+	var ln *line
+	for _, t := range toks {
+		if ln == nil || t.Row != ln.end_row {
+			ln = &line{row: t.Row}
+			f.lines = append(f.lines, ln)
+		}
+		ln.toks = append(ln.toks, t)
+		ln.end_row = t.EndRow()
+	}
+}
+
+func is_opener(t lexer.Token) bool {
+	return t.Id == lexer.ID_BRACE &&
+		(t.Kind == lexer.KND_LPAREN || t.Kind == lexer.KND_LBRACKET || t.Kind == lexer.KND_LBRACE)
+}
+
+func is_closer(t lexer.Token) bool {
+	return t.Id == lexer.ID_BRACE &&
+		(t.Kind == lexer.KND_RPARENT || t.Kind == lexer.KND_RBRACKET || t.Kind == lexer.KND_RBRACE)
+}
+
+func is_value_end(t lexer.Token) bool {
+	switch t.Id {
+	case lexer.ID_IDENT, lexer.ID_LITERAL, lexer.ID_DT, lexer.ID_SELF:
+		return true
+	case lexer.ID_BRACE:
+		return t.Kind == lexer.KND_RPARENT || t.Kind == lexer.KND_RBRACKET
+	}
+	return false
+}
+
+func is_keyword(t lexer.Token) bool {
+	switch t.Id {
+	case lexer.ID_NA, lexer.ID_DT, lexer.ID_IDENT, lexer.ID_BRACE,
+		lexer.ID_SEMICOLON, lexer.ID_LITERAL, lexer.ID_OP, lexer.ID_COMMA,
+		lexer.ID_COLON, lexer.ID_COMMENT, lexer.ID_DOT, lexer.ID_DBLCOLON,
+		lexer.ID_SELF:
+		return false
+	}
+	return true
+}
+
+// Reports whether token is postfix increment or decrement statement.
+func is_postfix(toks []lexer.Token, i int) bool {
+	t := toks[i]
+	if t.Id != lexer.ID_OP || (t.Kind != lexer.KND_DBL_PLUS && t.Kind != lexer.KND_DBL_MINUS) {
+		return false
+	}
+	if i == 0 || !is_value_end(toks[i-1]) {
+		return false
+	}
+	if i+1 == len(toks) {
+		return true
+	}
+	next := toks[i+1]
+	switch next.Id {
+	case lexer.ID_SEMICOLON, lexer.ID_COMMA:
+		return true
+	case lexer.ID_BRACE:
+		return next.Kind == lexer.KND_LBRACE || is_closer(next)
+	}
+	return false
+}
+
+// Reports whether operator always separated with spaces.
+func is_spaced_op(kind string) bool {
+	switch kind {
+	case lexer.KND_EQ, lexer.KND_PLUS_EQ, lexer.KND_MINUS_EQ, lexer.KND_STAR_EQ,
+		lexer.KND_SOLIDUS_EQ, lexer.KND_PERCENT_EQ, lexer.KND_LSHIFT_EQ,
+		lexer.KND_RSHIFT_EQ, lexer.KND_CARET_EQ, lexer.KND_AMPER_EQ,
+		lexer.KND_VLINE_EQ, lexer.KND_EQS, lexer.KND_NOT_EQ, lexer.KND_GREAT_EQ,
+		lexer.KND_LESS_EQ, lexer.KND_DBL_AMPER, lexer.KND_DBL_VLINE:
+		return true
+	}
+	return false
+}
+
+func is_unary_op(kind string) bool {
+	switch kind {
+	case lexer.KND_MINUS, lexer.KND_PLUS, lexer.KND_EXCL, lexer.KND_CARET,
+		lexer.KND_STAR, lexer.KND_AMPER, lexer.KND_TRIPLE_DOT:
+		return true
+	}
+	return false
+}
+
+// Returns code tokens of line, without trailing comment.
+func (ln *line) code_toks() []lexer.Token {
+	n := len(ln.toks)
+	if n > 0 && ln.toks[n-1].Id == lexer.ID_COMMENT {
+		return ln.toks[:n-1]
+	}
+	return ln.toks
+}
+
+func (f *formatter) top() *scope {
+	if len(f.scopes) == 0 {
+		return nil
+	}
+	return &f.scopes[len(f.scopes)-1]
+}
+
+func (f *formatter) line_indent(ln *line) int {
+	closers := 0
+	for closers < len(ln.toks) && is_closer(ln.toks[closers]) {
+		closers++
+	}
+	n := len(f.scopes) - closers
+	if n <= 0 {
+		return 0
+	}
+	s := &f.scopes[n-1]
+	if !s.match {
+		return s.indent
+	}
+	if closers == 0 && ln.toks[0].Id == lexer.ID_OP && ln.toks[0].Kind == lexer.KND_VLINE {
+		ln.is_case = true
+		s.in_case = true
+		return s.indent
+	}
+	if s.in_case {
+		return s.indent + 1
+	}
+	return s.indent
+}
+
+func (f *formatter) format_line(ln *line) {
+	ln.indent = f.line_indent(ln)
+	if f.cont {
+		ln.indent++
+	}
+	depth := len(f.scopes)
+	toks := ln.code_toks()
+	n := len(toks)
+	f.unary = make([]bool, n)
+	f.spaced = make([]bool, n)
+	f.prefix = make([]bool, n)
+	f.cur = ln
+	ln.eq_pos = -1
+	ln.body_pos = -1
+	case_colon := -1
+	var sb strings.Builder
+	for i, t := range toks {
+		pos := sb.Len()
+		if i == 0 {
+			if t.Id == lexer.ID_OP {
+				f.unary[i] = is_unary_op(t.Kind)
+				f.spaced[i] = !f.unary[i]
+			}
+		} else if f.space(toks, i) {
+			sb.WriteByte(' ')
+		}
+		if ln.eq_pos == -1 && t.Id == lexer.ID_OP && t.Kind == lexer.KND_EQ && len(f.scopes) == depth {
+			ln.eq_pos = pos
+		}
+		if ln.is_case && case_colon == -1 && t.Id == lexer.ID_COLON && len(f.scopes) == depth {
+			case_colon = i
+		} else if case_colon != -1 && case_colon == i-1 {
+			ln.body_pos = pos
+		}
+		f.push(toks, i)
+		sb.WriteString(t.Kind)
+	}
+	ln.code = sb.String()
+	ln.pad = 1
+	if len(toks) < len(ln.toks) {
+		ln.comment = comment_kind(ln.toks[len(ln.toks)-1])
+	}
+	f.cont = false
+	if n > 0 && len(f.scopes) == depth {
+		last := toks[n-1]
+		top := f.top()
+		f.cont = last.Id == lexer.ID_OP && last.Kind != lexer.KND_TRIPLE_DOT && !is_postfix(toks, n-1) && (top == nil || top.kind == lexer.KND_LBRACE)
+	}
+}
+
+func (f *formatter) push(toks []lexer.Token, i int) {
+	t := toks[i]
+	switch {
+	case is_opener(t):
+		s := scope{kind: t.Kind, indent: f.cur.indent + 1}
+		switch t.Kind {
+		case lexer.KND_LBRACE:
+			s.match = toks[0].Id == lexer.ID_MATCH
+		case lexer.KND_LBRACKET:
+			s.prefix = i == 0 || !is_value_end(toks[i-1])
+		}
+		f.scopes = append(f.scopes, s)
+	case is_closer(t):
+		if len(f.scopes) > 0 {
+			f.prefix[i] = f.top().prefix
+			f.scopes = f.scopes[:len(f.scopes)-1]
+		}
+	}
+}
+
+func (f *formatter) classify_op(toks []lexer.Token, i int) {
+	p, c := toks[i-1], toks[i]
+	binary := is_value_end(p) ||
+		(p.Id == lexer.ID_BRACE && p.Kind == lexer.KND_RBRACE) ||
+		is_postfix(toks, i-1)
+	if !binary && is_unary_op(c.Kind) {
+		f.unary[i] = true
+		return
+	}
+	f.spaced[i] = is_spaced_op(c.Kind) || gap(p, c) || (i+1 < len(toks) && gap(c, toks[i+1]))
+}
+
+// Reports whether tokens should be separated with space.
+// Spacing of source is kept for ambiguous cases.
+func (f *formatter) space(toks []lexer.Token, i int) bool {
+	p, c := toks[i-1], toks[i]
+	if c.Id == lexer.ID_OP {
+		f.classify_op(toks, i)
+	}
+	switch {
+	case c.Id == lexer.ID_COMMENT:
+		return true
+	case p.Id == lexer.ID_BRACE && (p.Kind == lexer.KND_LPAREN || p.Kind == lexer.KND_LBRACKET):
+		return false
+	case c.Id == lexer.ID_BRACE && (c.Kind == lexer.KND_RPARENT || c.Kind == lexer.KND_RBRACKET):
+		return false
+	case c.Id == lexer.ID_SEMICOLON && is_keyword(p):
+		return true
+	case c.Id == lexer.ID_COMMA || c.Id == lexer.ID_SEMICOLON:
+		return false
+	case p.Id == lexer.ID_COMMA || p.Id == lexer.ID_SEMICOLON:
+		return true
+	case c.Id == lexer.ID_DOT || p.Id == lexer.ID_DOT ||
+		c.Id == lexer.ID_DBLCOLON || p.Id == lexer.ID_DBLCOLON:
+		return false
+	case c.Id == lexer.ID_COLON:
+		// Keep separated colon of short variable declaration.
+		return i+1 < len(toks) && toks[i+1].Kind == lexer.KND_EQ && !gap(c, toks[i+1]) && gap(p, c)
+	case p.Id == lexer.ID_COLON && c.Kind == lexer.KND_EQ:
+		return gap(p, c)
+	case p.Id == lexer.ID_COLON:
+		// Slicing and map types.
+		top := f.top()
+		return top == nil || top.kind != lexer.KND_LBRACKET
+	case c.Id == lexer.ID_OP && c.Kind == lexer.KND_TRIPLE_DOT && is_value_end(p):
+		return false
+	case p.Id == lexer.ID_OP && p.Kind == lexer.KND_TRIPLE_DOT:
+		return false
+	case i == 1 && f.cur.is_case:
+		return true
+	}
+	if c.Id == lexer.ID_BRACE {
+		switch c.Kind {
+		case lexer.KND_LPAREN:
+			switch {
+			case is_value_end(p):
+				return false
+			case p.Id == lexer.ID_FN:
+				return gap(p, c)
+			case is_keyword(p):
+				return true
+			}
+		case lexer.KND_LBRACKET:
+			if is_value_end(p) {
+				return false
+			}
+		case lexer.KND_LBRACE:
+			switch {
+			case p.Id == lexer.ID_BRACE && p.Kind == lexer.KND_RPARENT, is_keyword(p):
+				return true
+			case is_value_end(p):
+				return gap(p, c)
+			}
+		case lexer.KND_RBRACE:
+			if p.Id == lexer.ID_BRACE && p.Kind == lexer.KND_LBRACE {
+				return false
+			}
+			return gap(p, c)
+		}
+	}
+	if p.Id == lexer.ID_BRACE && p.Kind == lexer.KND_RBRACKET && f.prefix[i-1] {
+		// Type prefixes such as []T and [N]T.
+		switch c.Id {
+		case lexer.ID_IDENT, lexer.ID_DT, lexer.ID_FN:
+			return false
+		case lexer.ID_BRACE:
+			if c.Kind == lexer.KND_LBRACKET || c.Kind == lexer.KND_LPAREN {
+				return false
+			}
+		case lexer.ID_OP:
+			if c.Kind == lexer.KND_STAR || c.Kind == lexer.KND_AMPER {
+				return false
+			}
+		}
+	}
+	if c.Id == lexer.ID_OP && !f.unary[i] {
+		if is_postfix(toks, i) {
+			return false
+		}
+		return f.spaced[i]
+	}
+	if p.Id == lexer.ID_OP {
+		switch {
+		case is_postfix(toks, i-1):
+			return true
+		case f.unary[i-1]:
+			return false
+		default:
+			return f.spaced[i-1]
+		}
+	}
+	switch {
+	case is_keyword(p):
+		return true
+	case p.Id == lexer.ID_BRACE && p.Kind == lexer.KND_RBRACE:
+		return c.Id == lexer.ID_IDENT || is_keyword(c) || gap(p, c)
+	case is_value_end(p) && (is_value_end(c) || is_keyword(c)):
+		return true
+	}
+	return gap(p, c)
+}
+
+// Comment lines before match cases are indented as cases,
+// unless they are indented deeper than case in source.
+func (f *formatter) fix_comment_indents() {
+	for i := len(f.lines) - 2; i >= 0; i-- {
+		ln, next := f.lines[i], f.lines[i+1]
+		if ln.code == "" && next.is_case && next.row == ln.end_row+1 &&
+			ln.toks[0].Column <= next.toks[0].Column {
+			ln.indent = next.indent
+			ln.is_case = true
+		}
+	}
+}
+
+// Reports whether line is constant or global variable definition.
+func (ln *line) is_def() bool {
+	toks := ln.code_toks()
+	if len(toks) > 1 && toks[0].Id == lexer.ID_PUB {
+		toks = toks[1:]
+	}
+	if len(toks) == 0 || ln.eq_pos == -1 {
+		return false
+	}
+	return toks[0].Id == lexer.ID_CONST || (toks[0].Id == lexer.ID_LET && ln.indent == 0)
+}
+
+// Returns runs of adjacent lines with same indentation that satisfies condition.
+func (f *formatter) runs(cond func(*line) bool) [][]*line {
+	var runs [][]*line
+	var run []*line
+	for _, ln := range f.lines {
+		if len(run) > 0 {
+			last := run[len(run)-1]
+			if !cond(ln) || ln.row != last.end_row+1 || ln.indent != last.indent {
+				runs = append(runs, run)
+				run = nil
+			}
+		}
+		if cond(ln) {
+			run = append(run, ln)
+		}
+	}
+	if len(run) > 0 {
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// Aligns assignments of adjacent definition lines.
+func (f *formatter) align_defs() {
+	for _, run := range f.runs((*line).is_def) {
+		max := 0
+		for _, ln := range run {
+			n := utf8.RuneCountInString(ln.code[:ln.eq_pos])
+			if n > max {
+				max = n
+			}
+		}
+		for _, ln := range run {
+			n := utf8.RuneCountInString(ln.code[:ln.eq_pos])
+			ln.code = ln.code[:ln.eq_pos] + strings.Repeat(" ", max-n) + ln.code[ln.eq_pos:]
+		}
+	}
+}
+
+// Aligns bodies of adjacent single line match cases.
+func (f *formatter) align_cases() {
+	has_body := func(ln *line) bool {
+		return ln.is_case && ln.body_pos != -1
+	}
+	for _, run := range f.runs(has_body) {
+		max := 0
+		for _, ln := range run {
+			n := utf8.RuneCountInString(ln.code[:ln.body_pos])
+			if n > max {
+				max = n
+			}
+		}
+		for _, ln := range run {
+			n := utf8.RuneCountInString(ln.code[:ln.body_pos])
+			ln.code = ln.code[:ln.body_pos] + strings.Repeat(" ", max-n) + ln.code[ln.body_pos:]
+		}
+	}
+}
+
+// Aligns trailing comments of adjacent lines.
+func (f *formatter) align_comments() {
+	has_comment := func(ln *line) bool {
+		return ln.code != "" && ln.comment != "" &&
+			!strings.Contains(ln.code, "\n") && !strings.Contains(ln.comment, "\n")
+	}
+	for _, run := range f.runs(has_comment) {
+		max := 0
+		for _, ln := range run {
+			n := utf8.RuneCountInString(ln.code)
+			if n > max {
+				max = n
+			}
+		}
+		for _, ln := range run {
+			ln.pad = max - utf8.RuneCountInString(ln.code) + 1
+		}
+	}
+}
+
+// Reports whether blank lines between lines should kept.
+func keep_blank(a *line, b *line) bool {
+	toks := a.code_toks()
+	if len(toks) > 0 && is_opener(toks[len(toks)-1]) {
+		return false
+	}
+	return !is_closer(b.toks[0])
+}
+
+func (f *formatter) write() []byte {
+	var sb strings.Builder
+	for i, ln := range f.lines {
+		if i > 0 {
+			prev := f.lines[i-1]
+			if ln.row > prev.end_row+1 && keep_blank(prev, ln) {
+				sb.WriteByte('\n')
+			}
+		}
+		sb.WriteString(strings.Repeat(INDENT, ln.indent))
+		sb.WriteString(ln.code)
+		if ln.comment != "" {
+			if ln.code != "" {
+				sb.WriteString(strings.Repeat(" ", ln.pad))
+			}
+			sb.WriteString(ln.comment)
+		}
+		sb.WriteByte('\n')
+	}
+	return []byte(sb.String())
+}
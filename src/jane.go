@@ -26,12 +26,29 @@ import (
 )
 
 const (
-	VERSION     = `@main`
-	EXT         = `.jn`
-	API         = "api"
-	STDLIB      = "std"
-	ENTRY_POINT = "main"
-	INIT_FN     = "init"
+	VERSION      = `@main`
+	EXT          = `.jn`
+	API          = "api"
+	STDLIB       = "std"
+	LOCALIZATION = "localization"
+	ENTRY_POINT  = "main"
+	INIT_FN      = "init"
+)
+
+// Exit codes of compiler.
+//
+// Failure of backend compiler exits with exit status of backend
+// compiler. EXIT_BACKEND is used if backend compiler could not be run
+// or terminated without exit status.
+const (
+	EXIT_SUCCESS = 0 // Compilation succeeded.
+	EXIT_DIAG    = 1 // Jane diagnostics reported.
+	EXIT_USAGE   = 2 // Invalid command, option or option value.
+	EXIT_SETUP   = 3 // Missing standard library, entry point or environment.
+	EXIT_BACKEND = 4 // Backend compiler could not be run or failed.
+	EXIT_TEST    = 5 // Tests failed.
+
+	EXIT_INTERRUPT = 130 // Interrupted by signal.
 )
 
 var (
@@ -41,10 +58,13 @@ var (
 	WORKING_PATH      string
 )
 
+// Additional library roots besides STDLIB_PATH.
+// Packages of root are used by name of root directory.
+var LIBRARY_PATHS []string
+
 func exit_err(msg string) {
 	println(msg)
-	const ERROR_EXIT_CODE = 0
-	os.Exit(ERROR_EXIT_CODE)
+	os.Exit(EXIT_SETUP)
 }
 
 func init() {
@@ -59,4 +79,5 @@ func init() {
 	EXEC_PATH = filepath.Dir(path)
 	path = filepath.Join(EXEC_PATH, "..")
 	STDLIB_PATH = filepath.Join(path, STDLIB)
+	LOCALIZATION_PATH = filepath.Join(path, LOCALIZATION)
 }
@@ -21,17 +21,24 @@
 package lexer
 
 import (
+	"hash/fnv"
 	"os"
 	"path/filepath"
-	"unsafe"
+	"strings"
+	"sync"
+
+	"github.com/DeRuneLabs/jane"
 )
 
 type File struct {
 	_path string
+
+	id      uint64
+	id_once sync.Once
 }
 
 func NewFile(path string) *File {
-	return &File{path}
+	return &File{_path: path}
 }
 
 func (f *File) IsOk() bool {
@@ -51,6 +58,118 @@ func (f *File) Name() string {
 	return filepath.Base(f._path)
 }
 
-func (f *File) Addr() uintptr {
-	return uintptr(unsafe.Pointer(f))
+// Returns stable identifier of file for mangling.
+// Computed once from path relative to root of its package's library,
+// so the same sources always produce the same identifier regardless
+// of working directory and install location.
+// Files of main package are not in any library,
+// path relative to main package is used for them.
+//
+// Identifier qualifies package-level declarations of file.
+// Declarations are unique by name within package, which is reported
+// as exist_id otherwise, so file and name together identify declaration.
+// Local declarations are emitted in their own C++ scopes.
+func (f *File) Id() uint64 {
+	f.id_once.Do(func() { f.id = file_id(f._path) })
+	return f.id
+}
+
+// Returns path of file relative to root of its library,
+// prefixed by name of library which is also used by use declarations.
+func library_rel_path(path string) string {
+	if rel, ok := rel_path(jane.STDLIB_PATH, path); ok {
+		return filepath.Join(jane.STDLIB, rel)
+	}
+	for _, root := range jane.LIBRARY_PATHS {
+		if rel, ok := rel_path(root, path); ok {
+			return filepath.Join(filepath.Base(root), rel)
+		}
+	}
+	return filepath.Base(path)
+}
+
+func file_id(path string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(filepath.ToSlash(library_rel_path(path))))
+	id := h.Sum64()
+	if id == 0 {
+		id = 1
+	}
+	return id
+}
+
+func rel_path(root string, path string) (string, bool) {
+	if root == "" {
+		return "", false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+var overlay = struct {
+	sync.Mutex
+	files map[string][]byte
+}{files: map[string][]byte{}}
+
+func overlay_key(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return abs
+}
+
+// Sets content to use for file instead of reading file.
+// Used by editors to check unsaved sources.
+func SetOverlay(path string, data []byte) {
+	overlay.Lock()
+	overlay.files[overlay_key(path)] = data
+	overlay.Unlock()
+}
+
+// Removes overlay content of file.
+func RemoveOverlay(path string) {
+	overlay.Lock()
+	delete(overlay.files, overlay_key(path))
+	overlay.Unlock()
+}
+
+// Returns content of file, overlay content is preferred.
+func ReadFile(path string) ([]byte, error) {
+	overlay.Lock()
+	data, ok := overlay.files[overlay_key(path)]
+	overlay.Unlock()
+	if ok {
+		return data, nil
+	}
+	return os.ReadFile(path)
+}
+
+// Returns line of file at row without line ending, overlay content is preferred.
+// Reports false if file or line is not exist.
+func SourceLine(path string, row int) (string, bool) {
+	if row < 1 {
+		return "", false
+	}
+	data, err := ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	lines := strings.Split(string(data), "\n")
+	if row > len(lines) {
+		return "", false
+	}
+	return strings.TrimSuffix(lines[row-1], "\r"), true
+}
+
+// Returns line of file at row.
+func (f *File) Line(row int) (string, bool) {
+	return SourceLine(f._path, row)
 }
@@ -21,7 +21,6 @@
 package lexer
 
 import (
-	"os"
 	"strings"
 	"unicode/utf8"
 
@@ -29,6 +28,9 @@ import (
 )
 
 type Lex struct {
+	// Keeps all comments as tokens, including trailing and range comments.
+	// Default is keeping only line comments that are first token of line.
+	KeepComments        bool
 	first_token_of_line bool
 	ranges              []Token
 	data                []rune
@@ -49,13 +51,7 @@ func New(f *File) *Lex {
 }
 
 func make_err(row int, col int, f *File, key string, args ...any) build.Log {
-	return build.Log{
-		Type:   build.ERR,
-		Row:    row,
-		Column: col,
-		Path:   f.Path(),
-		Text:   build.Errorf(key, args...),
-	}
+	return build.Err(row, col, row, col, f.Path(), key, args...)
 }
 
 func (l *Lex) push_err(key string, args ...any) {
@@ -63,11 +59,11 @@ func (l *Lex) push_err(key string, args ...any) {
 }
 
 func (l *Lex) push_err_tok(tok Token, key string) {
-	l.Logs = append(l.Logs, make_err(tok.Row, tok.Column, l.File, key))
+	l.Logs = append(l.Logs, build.Err(tok.Row, tok.Column, tok.EndRow(), tok.EndColumn(), l.File.Path(), key))
 }
 
 func (l *Lex) buff_data() {
-	bytes, err := os.ReadFile(l.File.Path())
+	bytes, err := ReadFile(l.File.Path())
 	if err != nil {
 		panic("buffering failed: " + err.Error())
 	}
@@ -76,6 +72,16 @@ func (l *Lex) buff_data() {
 
 func (l *Lex) Lex() []Token {
 	l.buff_data()
+	return l.lex()
+}
+
+// Lexes data instead of reading file of lexer.
+func (l *Lex) LexData(data []byte) []Token {
+	l.data = []rune(string(data))
+	return l.lex()
+}
+
+func (l *Lex) lex() []Token {
 	var toks []Token
 	l.Logs = nil
 	l.NewLine()
@@ -161,33 +167,39 @@ func (l *Lex) lex_line_comment(t *Token) {
 	l.Pos += 2
 	for ; l.Pos < len(l.data); l.Pos++ {
 		if l.data[l.Pos] == '\n' {
-			if l.first_token_of_line {
+			if l.first_token_of_line || l.KeepComments {
 				t.Id = ID_COMMENT
 				t.Kind = string(l.data[start:l.Pos])
 			}
 			return
 		}
 	}
-	if l.first_token_of_line {
+	if l.first_token_of_line || l.KeepComments {
 		t.Id = ID_COMMENT
 		t.Kind = string(l.data[start:])
 	}
 }
 
-func (l *Lex) lex_range_comment() {
+func (l *Lex) lex_range_comment(t *Token) {
+	start := l.Pos
 	l.Pos += 2
+	l.Column += 2
 	for ; l.Pos < len(l.data); l.Pos++ {
 		r := l.data[l.Pos]
 		if r == '\n' {
 			l.NewLine()
 			continue
 		}
-		l.Column += len(string(r))
-		if strings.HasPrefix(string(l.data[l.Pos]), KND_RNG_LCOMMENT) {
+		if r == '*' && l.Pos+1 < len(l.data) && l.data[l.Pos+1] == '/' {
 			l.Column += 2
 			l.Pos += 2
+			if l.KeepComments {
+				t.Id = ID_COMMENT
+				t.Kind = string(l.data[start:l.Pos])
+			}
 			return
 		}
+		l.Column += len(string(r))
 	}
 	l.push_err("missing_block_comment")
 }
@@ -557,6 +569,9 @@ func (l *Lex) lex_str(txt string) string {
 				l.Pos++
 				return ""
 			}
+			sb.WriteByte(ch)
+			l.Pos++
+			continue
 		}
 		r := l.get_rune(txt[i:], raw)
 		sb.WriteString(r)
@@ -755,7 +770,7 @@ func (l *Lex) Token() Token {
 		l.lex_line_comment(&t)
 		return t
 	case strings.HasPrefix(txt, KND_RNG_LCOMMENT):
-		l.lex_range_comment()
+		l.lex_range_comment(&t)
 		return t
 	case l.is_op(txt, KND_LPAREN, ID_BRACE, &t):
 		l.ranges = append(l.ranges, t)
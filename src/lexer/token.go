@@ -66,6 +66,48 @@ const (
 	ID_DEFER     = 37
 )
 
+// Names of token identifiers, indexed by identifier.
+var ID_NAMES = [...]string{
+	ID_NA:        "NA",
+	ID_DT:        "DT",
+	ID_IDENT:     "IDENT",
+	ID_BRACE:     "BRACE",
+	ID_RET:       "RET",
+	ID_SEMICOLON: "SEMICOLON",
+	ID_LITERAL:   "LITERAL",
+	ID_OP:        "OP",
+	ID_COMMA:     "COMMA",
+	ID_CONST:     "CONST",
+	ID_TYPE:      "TYPE",
+	ID_COLON:     "COLON",
+	ID_ITER:      "ITER",
+	ID_BREAK:     "BREAK",
+	ID_CONTINUE:  "CONTINUE",
+	ID_IN:        "IN",
+	ID_IF:        "IF",
+	ID_ELSE:      "ELSE",
+	ID_COMMENT:   "COMMENT",
+	ID_USE:       "USE",
+	ID_DOT:       "DOT",
+	ID_PUB:       "PUB",
+	ID_GOTO:      "GOTO",
+	ID_DBLCOLON:  "DBLCOLON",
+	ID_ENUM:      "ENUM",
+	ID_STRUCT:    "STRUCT",
+	ID_CO:        "CO",
+	ID_MATCH:     "MATCH",
+	ID_SELF:      "SELF",
+	ID_TRAIT:     "TRAIT",
+	ID_IMPL:      "IMPL",
+	ID_CPP:       "CPP",
+	ID_FALL:      "FALL",
+	ID_FN:        "FN",
+	ID_LET:       "LET",
+	ID_UNSAFE:    "UNSAFE",
+	ID_MUT:       "MUT",
+	ID_DEFER:     "DEFER",
+}
+
 const (
 	KND_DBLCOLON     = "::"
 	KND_COLON        = ":"
@@ -229,6 +271,22 @@ type Token struct {
 	Id     uint8
 }
 
+// Returns row of the last line of token.
+// Raw strings and block comments may span multiple lines.
+func (t *Token) EndRow() int {
+	return t.Row + strings.Count(t.Kind, "\n")
+}
+
+// Returns column right after the last byte of token,
+// in the last line of token.
+func (t *Token) EndColumn() int {
+	i := strings.LastIndexByte(t.Kind, '\n')
+	if i == -1 {
+		return t.Column + len(t.Kind)
+	}
+	return 1 + len(t.Kind[i+1:])
+}
+
 func (t *Token) Prec() int {
 	if t.Id != ID_OP {
 		return -1
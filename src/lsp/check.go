@@ -0,0 +1,297 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package lsp
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DeRuneLabs/jane"
+	"github.com/DeRuneLabs/jane/build"
+	"github.com/DeRuneLabs/jane/lexer"
+	"github.com/DeRuneLabs/jane/manifest"
+	"github.com/DeRuneLabs/jane/parser"
+)
+
+// Delay of checking after changes, typing keeps postponing check.
+const CHECK_DELAY = 250 * time.Millisecond
+
+// Last check state of package directory.
+type package_state struct {
+	dir       string
+	hash      uint64
+	p         *parser.Parser
+	timer     *time.Timer
+	trigger   string          // Uri of last changed document.
+	published map[string]bool // Uris that have published diagnostics.
+}
+
+// Schedules check of package of document.
+// Checks immediately if delay is false.
+func (s *Server) schedule(d *document, delay bool) {
+	dir := filepath.Dir(d.path)
+	s.mu.Lock()
+	st := s.pkgs[dir]
+	if st == nil {
+		st = &package_state{dir: dir, published: map[string]bool{}}
+		s.pkgs[dir] = st
+	}
+	st.trigger = d.uri
+	if st.timer != nil {
+		st.timer.Stop()
+	}
+	if !delay {
+		st.timer = nil
+		s.mu.Unlock()
+		s.check(st)
+		return
+	}
+	st.timer = time.AfterFunc(CHECK_DELAY, func() { s.check(st) })
+	s.mu.Unlock()
+}
+
+// Returns parsed package of directory, checks package if not checked yet.
+func (s *Server) parsed(dir string) *parser.Parser {
+	s.mu.Lock()
+	st := s.pkgs[dir]
+	if st == nil {
+		st = &package_state{dir: dir, published: map[string]bool{}}
+		s.pkgs[dir] = st
+	}
+	p := st.p
+	s.mu.Unlock()
+	if p == nil {
+		s.check(st)
+		s.mu.Lock()
+		p = st.p
+		s.mu.Unlock()
+	}
+	return p
+}
+
+// Returns source files of package directory.
+func package_files(dir string) []string {
+	dirents, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, dirent := range dirents {
+		name := dirent.Name()
+		if dirent.IsDir() ||
+			!strings.HasSuffix(name, jane.EXT) ||
+			!build.IsPassFileAnnotation(name) {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	return files
+}
+
+// Returns hash of package sources, unsaved contents included.
+func package_hash(dir string) uint64 {
+	h := fnv.New64a()
+	for _, path := range package_files(dir) {
+		data, err := lexer.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		_, _ = h.Write([]byte(path))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write(data)
+		_, _ = h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+// Parses package and publishes diagnostics.
+// Package is not parsed again if sources are not changed.
+// Otherwise unchanged files are not lexed again and unchanged used
+// packages, std included, are taken from parser cache.
+func (s *Server) check(st *package_state) {
+	// Parser has global state, so checks are serialized.
+	parse_mu.Lock()
+	defer parse_mu.Unlock()
+	hash := package_hash(st.dir)
+	s.mu.Lock()
+	if st.p != nil && st.hash == hash {
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+	var p *parser.Parser
+	var logs []build.Log
+	if err := s.use_project_libraries(st.dir); err != nil {
+		logs = []build.Log{{Type: build.FLAT_ERR, Text: err.Error()}}
+	} else {
+		p, logs = parse_package(st.dir)
+	}
+	s.mu.Lock()
+	st.hash = hash
+	if p != nil {
+		st.p = p
+	}
+	s.mu.Unlock()
+	s.publish(st, logs)
+}
+
+// Sets library roots for package of directory.
+// Library paths and vendored modules of project manifest are used,
+// so packages resolve as they do in build.
+// Must be called with parse_mu held.
+func (s *Server) use_project_libraries(dir string) error {
+	jane.LIBRARY_PATHS = append([]string(nil), s.library_paths...)
+	path := manifest.Find(dir)
+	if path == "" {
+		return nil
+	}
+	m, err := manifest.Load(path)
+	if err != nil {
+		return err
+	}
+	roots, err := m.LibraryRoots()
+	if err != nil {
+		return err
+	}
+	jane.LIBRARY_PATHS = append(jane.LIBRARY_PATHS, roots...)
+	return nil
+}
+
+// Parses package of directory.
+// Returns nil parser if parser fails, failure is reported by logs
+// so existing diagnostics are not cleared silently.
+func parse_package(dir string) (p *parser.Parser, logs []build.Log) {
+	defer func() {
+		if r := recover(); r != nil {
+			p = nil
+			logs = []build.Log{{Type: build.FLAT_ERR, Text: fmt.Sprint("internal compiler error: ", r)}}
+		}
+	}()
+	p, err_msg := parser.ParsePackage(dir, false)
+	if err_msg != "" {
+		return nil, []build.Log{{Type: build.FLAT_ERR, Text: err_msg}}
+	}
+	return p, p.Errors
+}
+
+// Publishes logs as diagnostics by files.
+// Files that have not logs anymore are cleared.
+func (s *Server) publish(st *package_state, logs []build.Log) {
+	files := map[string][]Diagnostic{}
+	s.mu.Lock()
+	trigger := st.trigger
+	s.mu.Unlock()
+	for _, log := range logs {
+		uri := trigger
+		if log.Type != build.FLAT_ERR && log.Path != "" {
+			uri = path_to_uri(log.Path)
+		}
+		files[uri] = append(files[uri], s.diagnostic(log))
+	}
+	s.mu.Lock()
+	for uri := range st.published {
+		if _, ok := files[uri]; !ok {
+			files[uri] = []Diagnostic{}
+		}
+	}
+	st.published = map[string]bool{}
+	for uri, diags := range files {
+		if len(diags) > 0 {
+			st.published[uri] = true
+		}
+	}
+	s.mu.Unlock()
+	uris := make([]string, 0, len(files))
+	for uri := range files {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	for _, uri := range uris {
+		_ = s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			Uri:         uri,
+			Diagnostics: files[uri],
+		})
+	}
+}
+
+// Returns diagnostic severity of log.
+func severity_of(log build.Log) int {
+	switch log.Level {
+	case build.WARNING:
+		return SEVERITY_WARNING
+	case build.NOTE:
+		return SEVERITY_INFO
+	default:
+		return SEVERITY_ERROR
+	}
+}
+
+// Returns diagnostic of log.
+func (s *Server) diagnostic(log build.Log) Diagnostic {
+	d := Diagnostic{
+		Severity: severity_of(log),
+		Code:     log.Key,
+		Source:   "jane",
+		Message:  log.Text,
+	}
+	// Notes without location have no place in related information,
+	// so they are appended to message.
+	for _, note := range log.Notes {
+		if note.Path == "" || note.Row < 1 {
+			d.Message += "\n" + note.Text
+			continue
+		}
+		d.RelatedInformation = append(d.RelatedInformation, DiagnosticRelatedInformation{
+			Location: Location{
+				Uri:   path_to_uri(note.Path),
+				Range: s.range_of(note.Path, note.Row, note.Column, note.Row, note.EndColumn),
+			},
+			Message: note.Text,
+		})
+	}
+	if log.Type == build.FLAT_ERR || log.Row < 1 {
+		return d
+	}
+	d.Range = s.range_of(log.Path, log.Row, log.Column, log.EndRow, log.EndColumn)
+	return d
+}
+
+// Returns range of position in file.
+// Range covers one character if end column is not after column.
+func (s *Server) range_of(path string, row int, column int, end_row int, end_column int) Range {
+	line := s.line(path, row)
+	var r Range
+	r.Start = Position{Line: row - 1, Character: char_of(line, column)}
+	r.End = r.Start
+	if end_row > row {
+		r.End = Position{Line: end_row - 1, Character: char_of(s.line(path, end_row), end_column)}
+	} else if end_column > column {
+		r.End.Character = char_of(line, end_column)
+	} else {
+		r.End.Character++
+	}
+	return r
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package lsp
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/DeRuneLabs/jane/lexer"
+)
+
+// Source file state, tokens are lexed once for each version.
+type document struct {
+	uri     string
+	path    string
+	version int
+	text    string
+	lines   []string
+	once    sync.Once
+	toks    []lexer.Token
+}
+
+// Returns document of text, unsaved text is used by parser too.
+func new_document(uri string, version int, text string) *document {
+	d := make_document(uri_to_path(uri), text)
+	d.uri = uri
+	d.version = version
+	lexer.SetOverlay(d.path, []byte(text))
+	return d
+}
+
+func make_document(path string, text string) *document {
+	return &document{
+		uri:   path_to_uri(path),
+		path:  path,
+		text:  text,
+		lines: strings.Split(text, "\n"),
+	}
+}
+
+func (d *document) close() {
+	lexer.RemoveOverlay(d.path)
+}
+
+// Returns tokens of document.
+func (d *document) tokens() []lexer.Token {
+	d.once.Do(func() {
+		l := lexer.New(lexer.NewFile(d.path))
+		d.toks = l.LexData([]byte(d.text))
+	})
+	return d.toks
+}
+
+// Returns line of row, rows start at 1.
+func (d *document) line(row int) string {
+	if row < 1 || row > len(d.lines) {
+		return ""
+	}
+	return strings.TrimSuffix(d.lines[row-1], "\r")
+}
+
+// Returns document of file, open document is preferred.
+// Documents of closed files are cached until file changes.
+func (s *Server) file(path string) *document {
+	uri := path_to_uri(path)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d := s.docs[uri]; d != nil {
+		return d
+	}
+	data, err := lexer.ReadFile(path)
+	if err != nil {
+		return make_document(path, "")
+	}
+	d := s.files[path]
+	if d == nil || d.text != string(data) {
+		d = make_document(path, string(data))
+		s.files[path] = d
+	}
+	return d
+}
+
+// Returns line of file at row.
+func (s *Server) line(path string, row int) string {
+	return s.file(path).line(row)
+}
+
+// Returns character offset of lexer column in line.
+// Lexer counts bytes, but tabs are four columns.
+// Characters of protocol are UTF-16 code units.
+func char_of(line string, column int) int {
+	col := 1
+	char := 0
+	for _, r := range line {
+		if col >= column {
+			break
+		}
+		if r == '\t' {
+			col += 4
+		} else {
+			col += utf8.RuneLen(r)
+		}
+		char += utf16_len(r)
+	}
+	if col < column {
+		char += column - col
+	}
+	return char
+}
+
+// Returns lexer column of character offset in line.
+func column_of(line string, char int) int {
+	col := 1
+	n := 0
+	for _, r := range line {
+		if n >= char {
+			break
+		}
+		if r == '\t' {
+			col += 4
+		} else {
+			col += utf8.RuneLen(r)
+		}
+		n += utf16_len(r)
+	}
+	return col
+}
+
+func utf16_len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// Returns range of token in line.
+func token_range(line string, tok lexer.Token) Range {
+	start := Position{Line: tok.Row - 1, Character: char_of(line, tok.Column)}
+	end := Position{Line: tok.Row - 1, Character: char_of(line, tok.EndColumn())}
+	return Range{Start: start, End: end}
+}
+
+// Returns index of token at position, -1 if there is no token.
+// Cursor right after identifier is accepted as on identifier.
+func token_at(toks []lexer.Token, row int, column int) int {
+	for i, tok := range toks {
+		if tok.Row != row {
+			if tok.Row > row {
+				break
+			}
+			continue
+		}
+		if column >= tok.Column && column < tok.EndColumn() {
+			return i
+		}
+		if column == tok.EndColumn() && tok.Id == lexer.ID_IDENT {
+			if i+1 >= len(toks) || toks[i+1].Row != row || toks[i+1].Column != column ||
+				toks[i+1].Id != lexer.ID_IDENT {
+				return i
+			}
+		}
+	}
+	return -1
+}
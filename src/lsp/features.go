@@ -0,0 +1,482 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package lsp
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/DeRuneLabs/jane"
+	"github.com/DeRuneLabs/jane/ast"
+	"github.com/DeRuneLabs/jane/doc"
+	"github.com/DeRuneLabs/jane/lexer"
+)
+
+var KEYWORDS = [...]string{
+	lexer.KND_CONST,
+	lexer.KND_RET,
+	lexer.KND_TYPE,
+	lexer.KND_ITER,
+	lexer.KND_BREAK,
+	lexer.KND_CONTINUE,
+	lexer.KND_IN,
+	lexer.KND_IF,
+	lexer.KND_ELSE,
+	lexer.KND_USE,
+	lexer.KND_PUB,
+	lexer.KND_GOTO,
+	lexer.KND_ENUM,
+	lexer.KND_STRUCT,
+	lexer.KND_CO,
+	lexer.KND_MATCH,
+	lexer.KND_SELF,
+	lexer.KND_TRAIT,
+	lexer.KND_IMPL,
+	lexer.KND_CPP,
+	lexer.KND_FALL,
+	lexer.KND_FN,
+	lexer.KND_LET,
+	lexer.KND_UNSAFE,
+	lexer.KND_MUT,
+	lexer.KND_DEFER,
+	lexer.KND_TRUE,
+	lexer.KND_FALSE,
+	lexer.KND_NIL,
+}
+
+// Returns resolver of document and index of token at position.
+func (s *Server) at(params json.RawMessage) (*resolver, int, error) {
+	var p TextDocumentPositionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, -1, err
+	}
+	d := s.file(uri_to_path(p.TextDocument.Uri))
+	r := s.resolver(d, s.parsed(filepath.Dir(d.path)))
+	row := p.Position.Line + 1
+	column := column_of(d.line(row), p.Position.Character)
+	return r, token_at(r.toks, row, column), nil
+}
+
+// Returns location of target.
+func (s *Server) location(t *target) *Location {
+	if t == nil || t.tok.File == nil {
+		return nil
+	}
+	path := t.tok.File.Path()
+	return &Location{
+		Uri:   path_to_uri(path),
+		Range: token_range(s.line(path, t.tok.Row), t.tok),
+	}
+}
+
+func (s *Server) definition(params json.RawMessage) (any, error) {
+	r, i, err := s.at(params)
+	if err != nil {
+		return nil, err
+	}
+	loc := s.location(r.resolve(i))
+	if loc == nil {
+		return nil, nil
+	}
+	return loc, nil
+}
+
+// Returns documentation comment of define.
+func define_doc(def any) string {
+	switch t := def.(type) {
+	case *ast.Fn:
+		return t.Doc
+	case *ast.Struct:
+		return t.Doc
+	case *ast.Enum:
+		return t.Doc
+	case *ast.Trait:
+		return t.Desc
+	case *ast.TypeAlias:
+		return t.Doc
+	case *ast.Var:
+		return t.Doc
+	}
+	return ""
+}
+
+// Returns signature of target in Jane syntax.
+func target_signature(t *target) string {
+	switch def := t.def.(type) {
+	case nil:
+		return t.detail
+	case *ast.EnumItem:
+		return def.Id
+	case *ast.Namespace:
+		return lexer.KND_USE + " " + def.Id
+	}
+	return doc.Signature(t.def)
+}
+
+func (s *Server) hover(params json.RawMessage) (any, error) {
+	r, i, err := s.at(params)
+	if err != nil {
+		return nil, err
+	}
+	t := r.resolve(i)
+	if t == nil {
+		return nil, nil
+	}
+	var md strings.Builder
+	md.WriteString("```jane\n")
+	md.WriteString(target_signature(t))
+	md.WriteString("\n```")
+	if text := strings.TrimSpace(define_doc(t.def)); text != "" {
+		md.WriteString("\n\n")
+		md.WriteString(text)
+	}
+	rng := token_range(r.d.line(r.toks[i].Row), r.toks[i])
+	return Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: md.String()},
+		Range:    &rng,
+	}, nil
+}
+
+func (s *Server) references(params json.RawMessage) (any, error) {
+	var p ReferenceParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	r, i, err := s.at(params)
+	if err != nil {
+		return nil, err
+	}
+	t := r.resolve(i)
+	if t == nil || t.tok.File == nil {
+		return nil, nil
+	}
+	// Locals are not visible out of file.
+	files := []string{r.d.path}
+	if t.def != nil {
+		files = package_files(filepath.Dir(r.d.path))
+	}
+	locs := []Location{}
+	for _, path := range files {
+		fr := s.resolver(s.file(path), r.p)
+		for j, tok := range fr.toks {
+			if tok.Kind != t.tok.Kind {
+				continue
+			}
+			ref := fr.resolve(j)
+			if !t.same(ref) {
+				continue
+			}
+			if !p.Context.IncludeDeclaration && tok.Row == t.tok.Row &&
+				tok.Column == t.tok.Column && path == t.tok.File.Path() {
+				continue
+			}
+			locs = append(locs, Location{
+				Uri:   fr.d.uri,
+				Range: token_range(fr.d.line(tok.Row), tok),
+			})
+		}
+	}
+	return locs, nil
+}
+
+// Returns true if define token is in document.
+func (s *Server) in_document(d *document, tok lexer.Token) bool {
+	return tok.File != nil && filepath.Clean(tok.File.Path()) == filepath.Clean(d.path)
+}
+
+func (s *Server) symbol(d *document, tok lexer.Token, id string, kind int, detail string) DocumentSymbol {
+	tok = s.name_token(tok, id)
+	rng := token_range(d.line(tok.Row), tok)
+	return DocumentSymbol{
+		Name:           id,
+		Detail:         detail,
+		Kind:           kind,
+		Range:          rng,
+		SelectionRange: rng,
+	}
+}
+
+func (s *Server) document_symbol(params json.RawMessage) (any, error) {
+	var p DocumentSymbolParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d := s.file(uri_to_path(p.TextDocument.Uri))
+	pkg := s.parsed(filepath.Dir(d.path))
+	symbols := []DocumentSymbol{}
+	if pkg == nil {
+		return symbols, nil
+	}
+	dm := pkg.Defines
+	for _, v := range dm.Globals {
+		if s.in_document(d, v.Token) {
+			kind := SYMBOL_VARIABLE
+			if v.Constant {
+				kind = SYMBOL_CONSTANT
+			}
+			symbols = append(symbols, s.symbol(d, v.Token, v.Id, kind, v.DataType.Kind))
+		}
+	}
+	for _, t := range dm.Types {
+		if s.in_document(d, t.Token) {
+			symbols = append(symbols, s.symbol(d, t.Token, t.Id, SYMBOL_TYPE_PARAM, t.TargetType.Kind))
+		}
+	}
+	for _, e := range dm.Enums {
+		if !s.in_document(d, e.Token) {
+			continue
+		}
+		sym := s.symbol(d, e.Token, e.Id, SYMBOL_ENUM, e.DataType.Kind)
+		for _, item := range e.Items {
+			sym.Children = append(sym.Children, s.symbol(d, item.Token, item.Id, SYMBOL_ENUM_MEMBER, ""))
+		}
+		symbols = append(symbols, sym)
+	}
+	for _, t := range dm.Traits {
+		if !s.in_document(d, t.Token) {
+			continue
+		}
+		sym := s.symbol(d, t.Token, t.Id, SYMBOL_INTERFACE, "")
+		for _, f := range t.Funcs {
+			sym.Children = append(sym.Children, s.symbol(d, f.Token, f.Id, SYMBOL_METHOD, doc.Signature(f)))
+		}
+		symbols = append(symbols, sym)
+	}
+	for _, st := range dm.Structs {
+		if !s.in_document(d, st.Token) {
+			continue
+		}
+		sym := s.symbol(d, st.Token, st.Id, SYMBOL_STRUCT, "")
+		for _, f := range st.Fields {
+			sym.Children = append(sym.Children, s.symbol(d, f.Token, f.Id, SYMBOL_FIELD, f.DataType.Kind))
+		}
+		for _, f := range st.Defines.Fns {
+			if s.in_document(d, f.Token) {
+				sym.Children = append(sym.Children, s.symbol(d, f.Token, f.Id, SYMBOL_METHOD, doc.Signature(f)))
+			}
+		}
+		symbols = append(symbols, sym)
+	}
+	for _, f := range dm.Fns {
+		if s.in_document(d, f.Token) {
+			symbols = append(symbols, s.symbol(d, f.Token, f.Id, SYMBOL_FUNCTION, doc.Signature(f)))
+		}
+	}
+	sort.SliceStable(symbols, func(i, j int) bool {
+		a, b := symbols[i].Range.Start, symbols[j].Range.Start
+		return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+	})
+	return symbols, nil
+}
+
+// Collects completion items, items are unique by label.
+type completions struct {
+	items []CompletionItem
+	seen  map[string]bool
+}
+
+func (c *completions) add(label string, kind int, detail string, text string) {
+	if label == "" || c.seen[label] {
+		return
+	}
+	c.seen[label] = true
+	item := CompletionItem{Label: label, Kind: kind, Detail: detail}
+	if text = strings.TrimSpace(text); text != "" {
+		item.Documentation = &MarkupContent{Kind: "markdown", Value: text}
+	}
+	c.items = append(c.items, item)
+}
+
+func (c *completions) add_define(def any) {
+	switch t := def.(type) {
+	case *ast.Var:
+		kind := COMPLETION_VARIABLE
+		if t.IsField {
+			kind = COMPLETION_FIELD
+		} else if t.Constant {
+			kind = COMPLETION_CONSTANT
+		}
+		c.add(t.Id, kind, doc.Signature(t), t.Doc)
+	case *ast.Fn:
+		kind := COMPLETION_FUNCTION
+		if t.Receiver != nil {
+			kind = COMPLETION_METHOD
+		}
+		c.add(t.Id, kind, doc.Signature(t), t.Doc)
+	case *ast.Struct:
+		c.add(t.Id, COMPLETION_STRUCT, doc.Signature(t), t.Doc)
+	case *ast.Enum:
+		c.add(t.Id, COMPLETION_ENUM, doc.Signature(t), t.Doc)
+	case *ast.Trait:
+		c.add(t.Id, COMPLETION_INTERFACE, doc.Signature(t), t.Desc)
+	case *ast.TypeAlias:
+		c.add(t.Id, COMPLETION_STRUCT, doc.Signature(t), t.Doc)
+	case *ast.EnumItem:
+		c.add(t.Id, COMPLETION_ENUM_MEMBER, "", "")
+	case *ast.Namespace:
+		c.add(t.Id, COMPLETION_MODULE, "", "")
+	}
+}
+
+// Adds defines of defmap, only public defines if pub is true.
+func (c *completions) add_defines(dm *ast.Defmap, pub bool) {
+	for ; dm != nil; dm = dm.Side {
+		for _, ns := range dm.Namespaces {
+			c.add_define(ns)
+		}
+		for _, v := range dm.Globals {
+			if !pub || v.Public {
+				c.add_define(v)
+			}
+		}
+		for _, f := range dm.Fns {
+			if !pub || f.Public {
+				c.add_define(f)
+			}
+		}
+		for _, s := range dm.Structs {
+			if !pub || s.Pub {
+				c.add_define(s)
+			}
+		}
+		for _, e := range dm.Enums {
+			if !pub || e.Pub {
+				c.add_define(e)
+			}
+		}
+		for _, t := range dm.Traits {
+			if !pub || t.Pub {
+				c.add_define(t)
+			}
+		}
+		for _, t := range dm.Types {
+			if !pub || t.Pub {
+				c.add_define(t)
+			}
+		}
+	}
+}
+
+// Adds fields and methods of struct.
+func (c *completions) add_members(s *ast.Struct) {
+	for _, f := range s.Fields {
+		c.add_define(f)
+	}
+	for _, f := range s.Defines.Fns {
+		c.add_define(f)
+	}
+}
+
+// Adds members of path that ends with double colon.
+func (c *completions) add_path(r *resolver, path []string) {
+	if len(path) > 0 && path[0] == jane.STDLIB {
+		dir := filepath.Join(append([]string{jane.STDLIB_PATH}, path[1:]...)...)
+		dirents, _ := os.ReadDir(dir)
+		for _, dirent := range dirents {
+			if dirent.IsDir() {
+				c.add(dirent.Name(), COMPLETION_MODULE, "", "")
+			}
+		}
+	}
+	if ns := r.namespace(path); ns != nil {
+		c.add_defines(ns.Defines, true)
+	}
+	if u := r.used(path); u != nil {
+		c.add_defines(u.Defines, true)
+	}
+	if len(path) == 0 {
+		return
+	}
+	owner := r.global(path[len(path)-1])
+	if owner == nil {
+		return
+	}
+	if e, ok := owner.def.(*ast.Enum); ok {
+		for _, item := range e.Items {
+			c.add_define(item)
+		}
+	}
+}
+
+// Returns index of last token that ends before column in row, -1 if not found.
+func last_token_before(toks []lexer.Token, row int, column int) int {
+	last := -1
+	for i, tok := range toks {
+		if tok.Row > row || (tok.Row == row && tok.EndColumn() > column) {
+			break
+		}
+		last = i
+	}
+	return last
+}
+
+func (s *Server) completion(params json.RawMessage) (any, error) {
+	var p TextDocumentPositionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d := s.file(uri_to_path(p.TextDocument.Uri))
+	r := s.resolver(d, s.parsed(filepath.Dir(d.path)))
+	c := &completions{seen: map[string]bool{}}
+	list := CompletionList{Items: []CompletionItem{}}
+	if r.p == nil {
+		return list, nil
+	}
+	row := p.Position.Line + 1
+	column := column_of(d.line(row), p.Position.Character)
+	i := last_token_before(r.toks, row, column)
+	// Identifier that is being typed is not part of context.
+	if i >= 0 && r.toks[i].Id == lexer.ID_IDENT && r.toks[i].Row == row &&
+		r.toks[i].EndColumn() == column {
+		i--
+	}
+	switch {
+	case i >= 0 && r.toks[i].Id == lexer.ID_DOT:
+		kind := r.type_of(i - 1)
+		if st := r.struct_of(kind); st != nil {
+			c.add_members(st)
+		} else if t := r.global(kind); t != nil {
+			if trait, ok := t.def.(*ast.Trait); ok {
+				for _, f := range trait.Funcs {
+					c.add_define(f)
+				}
+			}
+		}
+	case i >= 0 && r.toks[i].Id == lexer.ID_DBLCOLON:
+		c.add_path(r, r.path_before(i))
+	default:
+		locals := r.locals(i + 1)
+		for j := len(locals) - 1; j >= 0; j-- {
+			l := locals[j]
+			c.add(l.tok.Kind, COMPLETION_VARIABLE, strings.TrimSpace(d.line(l.tok.Row)), "")
+		}
+		c.add_defines(r.p.Defines, false)
+		c.add(jane.STDLIB, COMPLETION_MODULE, "", "")
+		for _, kw := range KEYWORDS {
+			c.add(kw, COMPLETION_KEYWORD, "", "")
+		}
+	}
+	list.Items = append(list.Items, c.items...)
+	return list, nil
+}
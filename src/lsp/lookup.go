@@ -0,0 +1,626 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package lsp
+
+import (
+	"strings"
+
+	"github.com/DeRuneLabs/jane/ast"
+	"github.com/DeRuneLabs/jane/lexer"
+	"github.com/DeRuneLabs/jane/parser"
+)
+
+// Definition found by lookup.
+type target struct {
+	tok    lexer.Token // Identifier token of definition.
+	def    any         // Define of AST, nil for locals.
+	kind   string      // Type kind of definition for member lookups.
+	detail string      // Declaration source of locals.
+}
+
+// Returns true if targets are same definition.
+func (t *target) same(other *target) bool {
+	if t == nil || other == nil || t.tok.File == nil || other.tok.File == nil {
+		return false
+	}
+	return t.tok.Row == other.tok.Row &&
+		t.tok.Column == other.tok.Column &&
+		t.tok.File.Path() == other.tok.File.Path()
+}
+
+// Resolves identifiers of tokens of file with defines of package.
+type resolver struct {
+	s    *Server
+	p    *parser.Parser
+	d    *document
+	toks []lexer.Token
+}
+
+func (s *Server) resolver(d *document, p *parser.Parser) *resolver {
+	return &resolver{s: s, p: p, d: d, toks: d.tokens()}
+}
+
+// Returns definition of identifier token at index, nil if not found.
+func (r *resolver) resolve(i int) *target {
+	if i < 0 || i >= len(r.toks) {
+		return nil
+	}
+	tok := r.toks[i]
+	switch tok.Id {
+	case lexer.ID_SELF:
+		return r.global(r.self_type(i))
+	case lexer.ID_IDENT:
+	default:
+		return nil
+	}
+	if i > 0 {
+		switch r.toks[i-1].Id {
+		case lexer.ID_DOT:
+			return r.member(r.type_of(i-2), tok.Kind)
+		case lexer.ID_DBLCOLON:
+			return r.path_member(r.path_before(i-1), tok.Kind)
+		}
+	}
+	if t := r.local(i, tok.Kind); t != nil {
+		return t
+	}
+	return r.global(tok.Kind)
+}
+
+// Returns target of define.
+func (r *resolver) def_target(def any) *target {
+	t := &target{def: def}
+	switch d := def.(type) {
+	case *ast.Var:
+		t.tok = d.Token
+		t.kind = d.DataType.Kind
+	case *ast.Fn:
+		t.tok = r.s.name_token(d.Token, d.Id)
+		t.kind = d.RetType.DataType.Kind
+	case *ast.Struct:
+		t.tok = d.Token
+		t.kind = d.Id
+	case *ast.Enum:
+		t.tok = d.Token
+		t.kind = d.Id
+	case *ast.Trait:
+		t.tok = d.Token
+		t.kind = d.Id
+	case *ast.TypeAlias:
+		t.tok = d.Token
+		t.kind = d.TargetType.Kind
+	case *ast.EnumItem:
+		t.tok = d.Token
+	case *ast.Namespace:
+		t.tok = d.Token
+	default:
+		return nil
+	}
+	return t
+}
+
+// Returns define of defmap by identifier.
+func find_define(dm *ast.Defmap, id string) any {
+	if dm == nil {
+		return nil
+	}
+	i, m, code := dm.FindById(id, nil)
+	if i == -1 {
+		return nil
+	}
+	switch code {
+	case 'g':
+		return m.Globals[i]
+	case 'f':
+		return m.Fns[i]
+	case 'e':
+		return m.Enums[i]
+	case 's':
+		return m.Structs[i]
+	case 't':
+		return m.Types[i]
+	case 'i':
+		return m.Traits[i]
+	}
+	return nil
+}
+
+// Returns definition of package scope by identifier.
+func (r *resolver) global(id string) *target {
+	if id == "" || r.p == nil {
+		return nil
+	}
+	if def := find_define(r.p.Defines, id); def != nil {
+		return r.def_target(def)
+	}
+	if ns := r.p.Defines.NsById(id); ns != nil {
+		return r.def_target(ns)
+	}
+	return nil
+}
+
+// Returns struct of type kind.
+// References, pointers, namespaces and generics of kind are ignored.
+func (r *resolver) struct_of(kind string) *ast.Struct {
+	if r.p == nil {
+		return nil
+	}
+	kind = strings.TrimLeft(kind, "&*")
+	kind = strings.TrimPrefix(kind, lexer.KND_MUT+" ")
+	if i := strings.Index(kind, lexer.KND_LBRACKET); i != -1 {
+		kind = kind[:i]
+	}
+	if i := strings.LastIndex(kind, lexer.KND_DBLCOLON); i != -1 {
+		kind = kind[i+len(lexer.KND_DBLCOLON):]
+	}
+	if kind == "" {
+		return nil
+	}
+	if s, _, _ := r.p.Defines.StructById(kind, nil); s != nil {
+		return s
+	}
+	for _, u := range *r.p.Used {
+		if u.Defines == nil {
+			continue
+		}
+		if s, _, _ := u.Defines.StructById(kind, nil); s != nil {
+			return s
+		}
+	}
+	return nil
+}
+
+// Returns field or method of struct by identifier.
+func struct_member(s *ast.Struct, id string) any {
+	for _, f := range s.Fields {
+		if f.Id == id {
+			return f
+		}
+	}
+	for _, f := range s.Defines.Fns {
+		if f.Id == id {
+			return f
+		}
+	}
+	return nil
+}
+
+// Returns member of type kind by identifier.
+// Members of all structs are searched if type is unknown.
+func (r *resolver) member(kind string, id string) *target {
+	if r.p == nil {
+		return nil
+	}
+	if s := r.struct_of(kind); s != nil {
+		if def := struct_member(s, id); def != nil {
+			return r.def_target(def)
+		}
+		return nil
+	}
+	if kind != "" {
+		if t := r.global(kind); t != nil {
+			if trait, ok := t.def.(*ast.Trait); ok {
+				for _, f := range trait.Funcs {
+					if f.Id == id {
+						return r.def_target(f)
+					}
+				}
+			}
+		}
+	}
+	for _, s := range r.p.Defines.Structs {
+		if def := struct_member(s, id); def != nil {
+			return r.def_target(def)
+		}
+	}
+	return nil
+}
+
+// Returns identifiers of path that ends with double colon at index.
+func (r *resolver) path_before(i int) []string {
+	var path []string
+	for i > 0 && r.toks[i].Id == lexer.ID_DBLCOLON {
+		tok := r.toks[i-1]
+		if tok.Id != lexer.ID_IDENT {
+			break
+		}
+		path = append([]string{tok.Kind}, path...)
+		i -= 2
+	}
+	return path
+}
+
+// Returns namespace of path.
+func (r *resolver) namespace(path []string) *ast.Namespace {
+	if r.p == nil || len(path) == 0 {
+		return nil
+	}
+	var ns *ast.Namespace
+	dm := r.p.Defines
+	for _, id := range path {
+		ns = dm.NsById(id)
+		if ns == nil {
+			return nil
+		}
+		dm = ns.Defines
+	}
+	return ns
+}
+
+// Returns used package of path.
+func (r *resolver) used(path []string) *ast.UseDecl {
+	if r.p == nil {
+		return nil
+	}
+	link := strings.Join(path, lexer.KND_DBLCOLON)
+	for _, u := range *r.p.Used {
+		if !u.Cpp && u.LinkString == link {
+			return u
+		}
+	}
+	return nil
+}
+
+// Returns member of path by identifier.
+func (r *resolver) path_member(path []string, id string) *target {
+	if len(path) == 0 {
+		return nil
+	}
+	if ns := r.namespace(path); ns != nil {
+		if def := find_define(ns.Defines, id); def != nil {
+			return r.def_target(def)
+		}
+		if sub := ns.Defines.NsById(id); sub != nil {
+			return r.def_target(sub)
+		}
+	}
+	if u := r.used(path); u != nil {
+		if def := find_define(u.Defines, id); def != nil {
+			return r.def_target(def)
+		}
+	}
+	owner := r.global(path[len(path)-1])
+	if owner == nil {
+		return nil
+	}
+	switch def := owner.def.(type) {
+	case *ast.Enum:
+		if item := def.ItemById(id); item != nil {
+			return r.def_target(item)
+		}
+	case *ast.Struct:
+		if f := struct_member(def, id); f != nil {
+			return r.def_target(f)
+		}
+	}
+	return nil
+}
+
+// Returns type kind of expression that ends at index.
+func (r *resolver) type_of(i int) string {
+	if i < 0 || i >= len(r.toks) {
+		return ""
+	}
+	tok := r.toks[i]
+	switch tok.Id {
+	case lexer.ID_SELF:
+		return r.self_type(i)
+	case lexer.ID_IDENT:
+		if t := r.resolve(i); t != nil {
+			return t.kind
+		}
+	case lexer.ID_BRACE:
+		open := r.opener(i)
+		if open < 1 {
+			return ""
+		}
+		switch tok.Kind {
+		case lexer.KND_RBRACE:
+			// Struct literal.
+			return r.toks[open-1].Kind
+		case lexer.KND_RPARENT:
+			// Function call.
+			if t := r.resolve(open - 1); t != nil {
+				if _, ok := t.def.(*ast.Fn); ok {
+					return t.kind
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// Returns index of opener brace of closer brace at index, -1 if not found.
+func (r *resolver) opener(i int) int {
+	close := r.toks[i].Kind
+	var open string
+	switch close {
+	case lexer.KND_RBRACE:
+		open = lexer.KND_LBRACE
+	case lexer.KND_RPARENT:
+		open = lexer.KND_LPAREN
+	case lexer.KND_RBRACKET:
+		open = lexer.KND_LBRACKET
+	default:
+		return -1
+	}
+	depth := 0
+	for ; i >= 0; i-- {
+		switch r.toks[i].Kind {
+		case close:
+			depth++
+		case open:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Returns indexes of opener braces of scopes that enclose index,
+// innermost scope is first.
+func (r *resolver) scopes(i int) []int {
+	var scopes []int
+	depth := 0
+	for i--; i >= 0; i-- {
+		tok := r.toks[i]
+		if tok.Id != lexer.ID_BRACE {
+			continue
+		}
+		switch tok.Kind {
+		case lexer.KND_RBRACE:
+			depth++
+		case lexer.KND_LBRACE:
+			if depth == 0 {
+				scopes = append(scopes, i)
+			} else {
+				depth--
+			}
+		}
+	}
+	return scopes
+}
+
+// Returns index of first token of line of token at index.
+func (r *resolver) line_start(i int) int {
+	row := r.toks[i].Row
+	for i > 0 && r.toks[i-1].Row == row {
+		i--
+	}
+	return i
+}
+
+// Returns receiver type identifier of impl block that encloses index.
+func (r *resolver) self_type(i int) string {
+	for _, open := range r.scopes(i) {
+		j := r.line_start(open)
+		if r.toks[j].Id != lexer.ID_IMPL {
+			continue
+		}
+		id := ""
+		for j++; j < open; j++ {
+			tok := r.toks[j]
+			if tok.Id == lexer.ID_ITER {
+				id = ""
+			} else if tok.Id == lexer.ID_IDENT && id == "" {
+				id = tok.Kind
+			}
+		}
+		return id
+	}
+	return ""
+}
+
+// Local declaration.
+type local struct {
+	tok  lexer.Token
+	kind string
+}
+
+// Returns index of outermost function header that encloses index.
+func (r *resolver) fn_begin(i int) int {
+	begin := -1
+	for _, open := range r.scopes(i) {
+		for j := r.line_start(open); j < open; j++ {
+			if r.toks[j].Id == lexer.ID_FN {
+				begin = j
+				break
+			}
+		}
+	}
+	return begin
+}
+
+// Returns type kind of tokens until one of stop kinds at zero depth.
+func (r *resolver) type_until(i int, stops ...string) string {
+	var kind strings.Builder
+	depth := 0
+	row := r.toks[i].Row
+	for ; i < len(r.toks) && r.toks[i].Row == row; i++ {
+		tok := r.toks[i]
+		if depth == 0 {
+			for _, stop := range stops {
+				if tok.Kind == stop {
+					return kind.String()
+				}
+			}
+		}
+		switch tok.Kind {
+		case lexer.KND_LPAREN, lexer.KND_LBRACKET:
+			depth++
+		case lexer.KND_RPARENT, lexer.KND_RBRACKET:
+			depth--
+			if depth < 0 {
+				return kind.String()
+			}
+		}
+		if tok.Id == lexer.ID_MUT {
+			kind.WriteString(tok.Kind + " ")
+			continue
+		}
+		kind.WriteString(tok.Kind)
+	}
+	return kind.String()
+}
+
+// Returns locals of function that are visible at index.
+// Locals of closed blocks are not visible.
+func (r *resolver) locals(i int) []local {
+	begin := r.fn_begin(i)
+	if begin == -1 {
+		return nil
+	}
+	scopes := [][]local{nil}
+	var pending []local
+	declare := func(j int, kind string) {
+		l := local{tok: r.toks[j], kind: kind}
+		top := len(scopes) - 1
+		scopes[top] = append(scopes[top], l)
+	}
+	// Identifiers at index are declared for next block.
+	param := func(j int, kind string) {
+		pending = append(pending, local{tok: r.toks[j], kind: kind})
+	}
+	paren := 0
+	header := false
+	for j := begin; j <= i && j < len(r.toks); j++ {
+		tok := r.toks[j]
+		switch tok.Id {
+		case lexer.ID_FN:
+			header = true
+			paren = 0
+		case lexer.ID_BRACE:
+			switch tok.Kind {
+			case lexer.KND_LBRACE:
+				scopes = append(scopes, pending)
+				pending = nil
+				header = false
+			case lexer.KND_RBRACE:
+				if len(scopes) > 1 {
+					scopes = scopes[:len(scopes)-1]
+				}
+			case lexer.KND_LPAREN:
+				paren++
+			case lexer.KND_RPARENT:
+				paren--
+			}
+		case lexer.ID_IDENT:
+			if !header || paren != 1 || j+1 >= len(r.toks) {
+				break
+			}
+			if r.toks[j+1].Id == lexer.ID_COLON {
+				param(j, r.type_until(j+2, lexer.KND_COMMA))
+			}
+		case lexer.ID_LET:
+			r.let_locals(j, declare)
+		case lexer.ID_ITER:
+			for k := j + 1; k < len(r.toks) && r.toks[k].Row == tok.Row; k++ {
+				if r.toks[k].Id == lexer.ID_IN {
+					for ; j < k; j++ {
+						if r.toks[j].Id == lexer.ID_IDENT {
+							param(j, "")
+						}
+					}
+					break
+				}
+			}
+		}
+	}
+	var locals []local
+	for _, scope := range scopes {
+		locals = append(locals, scope...)
+	}
+	return append(locals, pending...)
+}
+
+// Declares variables of let statement at index.
+func (r *resolver) let_locals(j int, declare func(int, string)) {
+	j++
+	if j < len(r.toks) && r.toks[j].Id == lexer.ID_MUT {
+		j++
+	}
+	if j >= len(r.toks) {
+		return
+	}
+	if r.toks[j].Kind == lexer.KND_LPAREN {
+		for j++; j < len(r.toks) && r.toks[j].Kind != lexer.KND_RPARENT; j++ {
+			if r.toks[j].Id == lexer.ID_IDENT {
+				declare(j, "")
+			}
+		}
+		return
+	}
+	if r.toks[j].Id != lexer.ID_IDENT {
+		return
+	}
+	kind := ""
+	k := j + 1
+	if k < len(r.toks) && r.toks[k].Id == lexer.ID_COLON {
+		kind = r.type_until(k+1, lexer.KND_EQ)
+	} else if k+1 < len(r.toks) && r.toks[k].Kind == lexer.KND_EQ {
+		// Struct literal initializer.
+		k++
+		for k < len(r.toks) && r.toks[k].Kind == lexer.KND_AMPER {
+			k++
+		}
+		if k+1 < len(r.toks) && r.toks[k].Id == lexer.ID_IDENT &&
+			r.toks[k+1].Kind == lexer.KND_LBRACE {
+			kind = r.toks[k].Kind
+		}
+	}
+	declare(j, kind)
+}
+
+// Returns local declaration of identifier visible at index.
+func (r *resolver) local(i int, id string) *target {
+	locals := r.locals(i)
+	for j := len(locals) - 1; j >= 0; j-- {
+		l := locals[j]
+		if l.tok.Kind != id {
+			continue
+		}
+		return &target{
+			tok:    l.tok,
+			kind:   l.kind,
+			detail: strings.TrimSpace(r.d.line(l.tok.Row)),
+		}
+	}
+	return nil
+}
+
+// Returns identifier token of definition token.
+// Token of definition may be keyword, such as functions.
+func (s *Server) name_token(tok lexer.Token, id string) lexer.Token {
+	if tok.Kind == id || tok.File == nil {
+		return tok
+	}
+	for _, t := range s.file(tok.File.Path()).tokens() {
+		if t.Row < tok.Row || (t.Row == tok.Row && t.Column <= tok.Column) {
+			continue
+		}
+		if t.Kind == id {
+			return t
+		}
+	}
+	return tok
+}
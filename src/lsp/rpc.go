@@ -0,0 +1,130 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"sync"
+)
+
+// JSON-RPC error codes.
+const (
+	ERR_PARSE            = -32700
+	ERR_INVALID_REQUEST  = -32600
+	ERR_METHOD_NOT_FOUND = -32601
+	ERR_INVALID_PARAMS   = -32602
+	ERR_INTERNAL         = -32603
+)
+
+type request struct {
+	Jsonrpc string           `json:"jsonrpc"`
+	Id      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+}
+
+type rpc_error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type response struct {
+	Jsonrpc string           `json:"jsonrpc"`
+	Id      *json.RawMessage `json:"id"`
+	Result  any              `json:"result"`
+	Error   *rpc_error       `json:"error,omitempty"`
+}
+
+type notification struct {
+	Jsonrpc string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// Connection of base protocol, messages are framed with headers.
+type conn struct {
+	r  *bufio.Reader
+	w  io.Writer
+	mu sync.Mutex
+}
+
+func new_conn(r io.Reader, w io.Writer) *conn {
+	return &conn{r: bufio.NewReader(r), w: w}
+}
+
+func (c *conn) read() (*request, error) {
+	header, err := textproto.NewReader(c.r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, errors.New("invalid Content-Length header")
+	}
+	content := make([]byte, length)
+	_, err = io.ReadFull(c.r, content)
+	if err != nil {
+		return nil, err
+	}
+	req := new(request)
+	err = json.Unmarshal(content, req)
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (c *conn) write(msg any) error {
+	content, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err = fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n", len(content))
+	if err != nil {
+		return err
+	}
+	_, err = c.w.Write(content)
+	return err
+}
+
+func (c *conn) reply(id *json.RawMessage, result any) error {
+	return c.write(response{Jsonrpc: "2.0", Id: id, Result: result})
+}
+
+func (c *conn) reply_err(id *json.RawMessage, code int, msg string) error {
+	return c.write(response{
+		Jsonrpc: "2.0",
+		Id:      id,
+		Error:   &rpc_error{Code: code, Message: msg},
+	})
+}
+
+func (c *conn) notify(method string, params any) error {
+	return c.write(notification{Jsonrpc: "2.0", Method: method, Params: params})
+}
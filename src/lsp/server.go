@@ -0,0 +1,260 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package lsp
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/DeRuneLabs/jane"
+	"github.com/DeRuneLabs/jane/parser"
+)
+
+// Server of Language Server Protocol for Jane sources.
+type Server struct {
+	conn     *conn
+	root     string
+	mu       sync.Mutex
+	docs     map[string]*document
+	files    map[string]*document
+	pkgs     map[string]*package_state
+	shutdown bool
+	// Library roots given by command, roots of projects are added to them.
+	library_paths []string
+}
+
+// Guards global state of parser, parser cache and library roots.
+// State is shared by all servers of process, so it is not per server.
+var parse_mu sync.Mutex
+
+// Returns new server communicates over r and w.
+// Parser cache is enabled, so checks lex and parse only changed
+// files and used packages.
+func New(r io.Reader, w io.Writer) *Server {
+	parse_mu.Lock()
+	if parser.CACHE == nil {
+		parser.CACHE = parser.NewCache()
+	}
+	library_paths := append([]string(nil), jane.LIBRARY_PATHS...)
+	parse_mu.Unlock()
+	return &Server{
+		conn:  new_conn(r, w),
+		docs:  map[string]*document{},
+		files: map[string]*document{},
+		pkgs:  map[string]*package_state{},
+
+		library_paths: library_paths,
+	}
+}
+
+// Serves requests until exit notification or end of input.
+// Returns true if server exited after shutdown request.
+func (s *Server) Serve() bool {
+	for {
+		req, err := s.conn.read()
+		if err != nil {
+			if err == io.EOF {
+				return s.shutdown
+			}
+			_ = s.conn.reply_err(nil, ERR_PARSE, err.Error())
+			continue
+		}
+		if req.Method == "exit" {
+			return s.shutdown
+		}
+		s.handle(req)
+	}
+}
+
+func (s *Server) handle(req *request) {
+	defer func() {
+		// Parser may panic on incomplete sources, server must survive.
+		if r := recover(); r != nil && req.Id != nil {
+			_ = s.conn.reply_err(req.Id, ERR_INTERNAL, "internal error")
+		}
+	}()
+	var result any
+	var err error
+	switch req.Method {
+	case "initialize":
+		result, err = s.initialize(req.Params)
+	case "initialized", "$/cancelRequest", "$/setTrace", "workspace/didChangeConfiguration":
+		return
+	case "shutdown":
+		s.shutdown = true
+	case "textDocument/didOpen":
+		err = s.did_open(req.Params)
+	case "textDocument/didChange":
+		err = s.did_change(req.Params)
+	case "textDocument/didClose":
+		err = s.did_close(req.Params)
+	case "textDocument/didSave":
+		err = s.did_save(req.Params)
+	case "textDocument/definition":
+		result, err = s.definition(req.Params)
+	case "textDocument/hover":
+		result, err = s.hover(req.Params)
+	case "textDocument/references":
+		result, err = s.references(req.Params)
+	case "textDocument/documentSymbol":
+		result, err = s.document_symbol(req.Params)
+	case "textDocument/completion":
+		result, err = s.completion(req.Params)
+	default:
+		if req.Id != nil {
+			_ = s.conn.reply_err(req.Id, ERR_METHOD_NOT_FOUND, "method not found: "+req.Method)
+		}
+		return
+	}
+	if req.Id == nil {
+		return
+	}
+	if err != nil {
+		_ = s.conn.reply_err(req.Id, ERR_INVALID_PARAMS, err.Error())
+		return
+	}
+	_ = s.conn.reply(req.Id, result)
+}
+
+func (s *Server) initialize(params json.RawMessage) (any, error) {
+	var p InitializeParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	s.root = uri_to_path(p.RootUri)
+	return map[string]any{
+		"capabilities": map[string]any{
+			"textDocumentSync": map[string]any{
+				"openClose": true,
+				"change":    1, // Full
+				"save":      true,
+			},
+			"definitionProvider":     true,
+			"hoverProvider":          true,
+			"referencesProvider":     true,
+			"documentSymbolProvider": true,
+			"completionProvider": map[string]any{
+				"triggerCharacters": []string{".", ":"},
+			},
+		},
+		"serverInfo": map[string]string{
+			"name":    "jane",
+			"version": jane.VERSION,
+		},
+	}, nil
+}
+
+func (s *Server) did_open(params json.RawMessage) error {
+	var p DidOpenTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	d := new_document(p.TextDocument.Uri, p.TextDocument.Version, p.TextDocument.Text)
+	s.mu.Lock()
+	s.docs[d.uri] = d
+	s.mu.Unlock()
+	s.schedule(d, false)
+	return nil
+}
+
+func (s *Server) did_change(params json.RawMessage) error {
+	var p DidChangeTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	if len(p.ContentChanges) == 0 {
+		return nil
+	}
+	// Server registers full synchronization, last change is whole text.
+	text := p.ContentChanges[len(p.ContentChanges)-1].Text
+	d := new_document(p.TextDocument.Uri, p.TextDocument.Version, text)
+	s.mu.Lock()
+	s.docs[d.uri] = d
+	s.mu.Unlock()
+	s.schedule(d, true)
+	return nil
+}
+
+func (s *Server) did_close(params json.RawMessage) error {
+	var p DidCloseTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	d := s.docs[p.TextDocument.Uri]
+	delete(s.docs, p.TextDocument.Uri)
+	s.mu.Unlock()
+	if d != nil {
+		d.close()
+		s.schedule(d, false)
+	}
+	return nil
+}
+
+func (s *Server) did_save(params json.RawMessage) error {
+	var p DidSaveTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	if d := s.doc(p.TextDocument.Uri); d != nil {
+		s.schedule(d, false)
+	}
+	return nil
+}
+
+// Returns open document of uri, nil if not open.
+func (s *Server) doc(uri string) *document {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.docs[uri]
+}
+
+// Returns file path of uri.
+func uri_to_path(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/")
+	}
+	return filepath.Clean(filepath.FromSlash(path))
+}
+
+// Returns uri of file path.
+func path_to_uri(path string) string {
+	abs, err := filepath.Abs(path)
+	if err == nil {
+		path = abs
+	}
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	u := url.URL{Scheme: "file", Path: path}
+	return u.String()
+}
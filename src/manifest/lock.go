@@ -0,0 +1,191 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// File name of lock file.
+const LOCK_FILE_NAME = "jane.lock"
+
+// Prefix of content hashes.
+const HASH_PREFIX = "sha256:"
+
+// Lock is lock file of project.
+// Records vendored modules with their content hashes.
+type Lock struct {
+	Modules []LockedModule // Sorted by name.
+}
+
+// LockedModule is module recorded by lock file.
+type LockedModule struct {
+	Name    string
+	Version string
+	Source  string // Source of module, relative to project root if local.
+	Hash    string
+}
+
+// Returns locked module by name, nil if not exist.
+func (l *Lock) Module(name string) *LockedModule {
+	for i := range l.Modules {
+		if l.Modules[i].Name == name {
+			return &l.Modules[i]
+		}
+	}
+	return nil
+}
+
+// Loads lock file.
+// Returns empty lock if file is not exist.
+func LoadLock(path string) (*Lock, error) {
+	bytes, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Lock{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	tables, err := parse_toml(string(bytes))
+	if err != nil {
+		return nil, fmt.Errorf("%s:%s", path, err.Error())
+	}
+	lock := &Lock{}
+	l := loader{path: path}
+	for name, t := range tables {
+		if name == "" {
+			continue
+		}
+		if !strings.HasPrefix(name, DEPENDENCY_TABLE) {
+			return nil, fmt.Errorf("%s: unknown table: %s", path, name)
+		}
+		lock.Modules = append(lock.Modules, LockedModule{
+			Name:    name[len(DEPENDENCY_TABLE):],
+			Version: l.str(t, name, "version"),
+			Source:  l.str(t, name, "source"),
+			Hash:    l.str(t, name, "hash"),
+		})
+	}
+	if l.err != nil {
+		return nil, l.err
+	}
+	lock.sort()
+	return lock, nil
+}
+
+func (l *Lock) sort() {
+	sort.Slice(l.Modules, func(i, j int) bool {
+		return l.Modules[i].Name < l.Modules[j].Name
+	})
+}
+
+// Returns lock file content.
+func (l *Lock) String() string {
+	var sb strings.Builder
+	sb.WriteString("# Generated by jane mod vendor, do not edit.\n")
+	for _, m := range l.Modules {
+		sb.WriteString("\n[")
+		sb.WriteString(DEPENDENCY_TABLE)
+		sb.WriteString(m.Name)
+		sb.WriteString("]\n")
+		if m.Version != "" {
+			sb.WriteString("version = ")
+			sb.WriteString(strconv.Quote(m.Version))
+			sb.WriteByte('\n')
+		}
+		sb.WriteString("source = ")
+		sb.WriteString(strconv.Quote(m.Source))
+		sb.WriteString("\nhash = ")
+		sb.WriteString(strconv.Quote(m.Hash))
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// Writes lock file.
+func (l *Lock) Write(path string) error {
+	return os.WriteFile(path, []byte(l.String()), 0o644)
+}
+
+// Reports whether entry of directory is not part of module content.
+// Hidden entries, vendor directory and manifest lock are skipped.
+func skip_module_entry(root string, path string, d fs.DirEntry) bool {
+	name := d.Name()
+	if path == root {
+		return false
+	}
+	if strings.HasPrefix(name, ".") {
+		return true
+	}
+	if filepath.Dir(path) == root {
+		return name == VENDOR_DIR || name == LOCK_FILE_NAME
+	}
+	return false
+}
+
+// Returns content hash of module directory.
+// Hash covers relative paths and contents of files in sorted order.
+func HashDir(root string) (string, error) {
+	h := sha256.New()
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if skip_module_entry(root, path, d) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", filepath.ToSlash(rel), info.Size())
+		_, err = io.Copy(h, f)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return HASH_PREFIX + hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,319 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// File name of project manifest.
+const FILE_NAME = "jane.toml"
+
+// Directory name of vendored modules in project root.
+const VENDOR_DIR = "vendor"
+
+// Prefix of dependency table names.
+const DEPENDENCY_TABLE = "dependencies."
+
+// Manifest is project manifest.
+// Paths are absolute, resolved relative to the manifest directory.
+type Manifest struct {
+	Path         string // Path of manifest file.
+	Root         string // Directory of manifest file.
+	Name         string
+	Entry        string
+	Out          string
+	Compiler     string
+	Profile      string
+	TargetOs     string
+	TargetArch   string
+	CxxFlags     []string
+	LdFlags      []string
+	IncludeDirs  []string
+	Libs         []string
+	LibraryPaths []string
+	LintAllow    []string
+	LintWarn     []string
+	LintDeny     []string
+	Dependencies []Dependency // Sorted by name.
+}
+
+// Dependency is module required by project.
+//
+// Module is taken from local directory if Path is set,
+// from local archive if Archive is set, from registry otherwise.
+type Dependency struct {
+	Name    string
+	Version string
+	Path    string
+	Archive string
+}
+
+// Returns vendor directory of project.
+func (m *Manifest) VendorDir() string { return filepath.Join(m.Root, VENDOR_DIR) }
+
+// Returns lock file path of project.
+func (m *Manifest) LockPath() string { return filepath.Join(m.Root, LOCK_FILE_NAME) }
+
+// Returns library roots of project, library paths and vendored modules.
+// Modules of lock file are used, so dependencies of dependencies are included.
+// Fails if any dependency is not vendored.
+func (m *Manifest) LibraryRoots() ([]string, error) {
+	roots := append([]string(nil), m.LibraryPaths...)
+	if len(m.Dependencies) == 0 {
+		return roots, nil
+	}
+	lock, err := LoadLock(m.LockPath())
+	if err != nil {
+		return nil, err
+	}
+	for _, dep := range m.Dependencies {
+		if lock.Module(dep.Name) == nil {
+			return nil, fmt.Errorf("module is not vendored: %s\n"+
+				"run \"jane mod vendor\" to vendor dependencies", dep.Name)
+		}
+	}
+	for _, locked := range lock.Modules {
+		roots = append(roots, filepath.Join(m.VendorDir(), locked.Name))
+	}
+	return roots, nil
+}
+
+// Returns path of manifest by walking up from dir.
+// Returns empty string if not found.
+func Find(dir string) string {
+	for {
+		path := filepath.Join(dir, FILE_NAME)
+		info, err := os.Stat(path)
+		if err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Loads manifest file.
+func Load(path string) (*Manifest, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := parse_toml(string(bytes))
+	if err != nil {
+		return nil, fmt.Errorf("%s:%s", path, err.Error())
+	}
+	m := &Manifest{Path: path, Root: filepath.Dir(path)}
+	l := loader{m: m, path: path}
+	l.load(tables)
+	if l.err != nil {
+		return nil, l.err
+	}
+	if m.Entry == "" {
+		m.Entry = m.Root
+	}
+	return m, nil
+}
+
+type loader struct {
+	m    *Manifest
+	path string
+	err  error
+}
+
+func (l *loader) fail(table string, key string, msg string) {
+	if l.err != nil {
+		return
+	}
+	if table != "" {
+		key = table + "." + key
+	}
+	l.err = fmt.Errorf("%s: %s: %s", l.path, key, msg)
+}
+
+// Returns keys of map in sorted order,
+// so the first reported error does not depend on map order.
+func sorted_keys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Fails for keys of table that are not one of keys.
+func (l *loader) check_keys(t Table, table string, keys ...string) {
+	for _, key := range sorted_keys(t) {
+		if !slices.Contains(keys, key) {
+			l.fail(table, key, "unknown key")
+		}
+	}
+}
+
+func (l *loader) str(t Table, table string, key string) string {
+	v, ok := t[key]
+	if !ok {
+		return ""
+	}
+	s, ok := v.(string)
+	if !ok {
+		l.fail(table, key, "expected string")
+	}
+	return s
+}
+
+func (l *loader) strs(t Table, table string, key string) []string {
+	v, ok := t[key]
+	if !ok {
+		return nil
+	}
+	items, ok := v.([]any)
+	if !ok {
+		l.fail(table, key, "expected array of strings")
+		return nil
+	}
+	strs := make([]string, len(items))
+	for i, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			l.fail(table, key, "expected array of strings")
+			return nil
+		}
+		strs[i] = s
+	}
+	return strs
+}
+
+func (l *loader) path_of(p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(l.m.Root, p)
+}
+
+func (l *loader) paths_of(paths []string) []string {
+	for i, p := range paths {
+		paths[i] = l.path_of(p)
+	}
+	return paths
+}
+
+func (l *loader) load(tables map[string]Table) {
+	for _, name := range sorted_keys(tables) {
+		switch name {
+		case "", "package", "build", "lint":
+		default:
+			if strings.HasPrefix(name, DEPENDENCY_TABLE) {
+				break
+			}
+			if l.err == nil {
+				l.err = fmt.Errorf("%s: unknown table: %s", l.path, name)
+			}
+		}
+	}
+
+	l.check_keys(tables[""], "")
+	l.check_keys(tables["package"], "package", "name", "entry", "out")
+	l.check_keys(tables["build"], "build",
+		"compiler", "profile", "target_os", "target_arch",
+		"cxxflags", "ldflags", "include", "libs", "library_paths")
+	l.check_keys(tables["lint"], "lint", "allow", "warn", "deny")
+
+	pkg := tables["package"]
+	l.m.Name = l.str(pkg, "package", "name")
+	l.m.Entry = l.path_of(l.str(pkg, "package", "entry"))
+	l.m.Out = l.path_of(l.str(pkg, "package", "out"))
+
+	b := tables["build"]
+	l.m.Compiler = l.str(b, "build", "compiler")
+	l.m.Profile = l.str(b, "build", "profile")
+	l.m.TargetOs = l.str(b, "build", "target_os")
+	l.m.TargetArch = l.str(b, "build", "target_arch")
+	l.m.CxxFlags = l.strs(b, "build", "cxxflags")
+	l.m.LdFlags = l.strs(b, "build", "ldflags")
+	l.m.IncludeDirs = l.paths_of(l.strs(b, "build", "include"))
+	l.m.Libs = l.strs(b, "build", "libs")
+	l.m.LibraryPaths = l.paths_of(l.strs(b, "build", "library_paths"))
+
+	lint := tables["lint"]
+	l.m.LintAllow = l.strs(lint, "lint", "allow")
+	l.m.LintWarn = l.strs(lint, "lint", "warn")
+	l.m.LintDeny = l.strs(lint, "lint", "deny")
+
+	l.load_dependencies(tables)
+}
+
+func (l *loader) load_dependencies(tables map[string]Table) {
+	for _, name := range sorted_keys(tables) {
+		if !strings.HasPrefix(name, DEPENDENCY_TABLE) {
+			continue
+		}
+		t := tables[name]
+		dep := Dependency{Name: name[len(DEPENDENCY_TABLE):]}
+		if !is_module_name(dep.Name) {
+			if l.err == nil {
+				l.err = fmt.Errorf("%s: invalid module name: %s", l.path, dep.Name)
+			}
+			continue
+		}
+		l.check_keys(t, name, "version", "path", "archive")
+		dep.Version = l.str(t, name, "version")
+		dep.Path = l.path_of(l.str(t, name, "path"))
+		dep.Archive = l.path_of(l.str(t, name, "archive"))
+		switch {
+		case dep.Path != "" && dep.Archive != "":
+			l.fail(name, "archive", "path and archive cannot be used together")
+		case dep.Path == "" && dep.Version == "":
+			l.fail(name, "version", "missing version")
+		}
+		l.m.Dependencies = append(l.m.Dependencies, dep)
+	}
+	sort.Slice(l.m.Dependencies, func(i, j int) bool {
+		return l.m.Dependencies[i].Name < l.m.Dependencies[j].Name
+	})
+}
+
+// Reports whether name is valid module name.
+// Module names are used as root of use declarations, so must be identifier
+// and cannot shadow standard library.
+func is_module_name(name string) bool {
+	if name == "" || name == "std" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case i > 0 && '0' <= r && r <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
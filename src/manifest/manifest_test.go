@@ -0,0 +1,103 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Writes manifest source into temporary directory and loads it.
+func load_source(t *testing.T, src string) (*Manifest, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), FILE_NAME)
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return Load(path)
+}
+
+func TestLoad(t *testing.T) {
+	m, err := load_source(t, `[package]
+name = "app"
+entry = "src"
+
+[build]
+profile = "release"
+cxxflags = ["-O3", "-flto"]
+
+[lint]
+deny = ["unused_variable"]
+
+[dependencies.json]
+version = "1.0.0"
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Name != "app" || m.Entry != filepath.Join(m.Root, "src") {
+		t.Errorf("got name %q and entry %q", m.Name, m.Entry)
+	}
+	if m.Profile != "release" || !reflect.DeepEqual(m.CxxFlags, []string{"-O3", "-flto"}) {
+		t.Errorf("got profile %q and cxxflags %q", m.Profile, m.CxxFlags)
+	}
+	if !reflect.DeepEqual(m.LintDeny, []string{"unused_variable"}) {
+		t.Errorf("got lint.deny %q", m.LintDeny)
+	}
+	want := []Dependency{{Name: "json", Version: "1.0.0"}}
+	if !reflect.DeepEqual(m.Dependencies, want) {
+		t.Errorf("got dependencies %+v, want %+v", m.Dependencies, want)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"root key", "name = \"app\"", "name: unknown key"},
+		{"package key", "[package]\nversion = \"1\"", "package.version: unknown key"},
+		{"build key", "[build]\nflags = []", "build.flags: unknown key"},
+		{"lint key", "[lint]\nerror = []", "lint.error: unknown key"},
+		{"dependency key", "[dependencies.json]\nversion = \"1\"\nurl = \"x\"", "dependencies.json.url: unknown key"},
+		{"sorted keys", "[build]\nz = 1\nb = 2\nm = 3", "build.b: unknown key"},
+		{"sorted tables", "[z]\n[b]\n[m]", "unknown table: b"},
+		{"type", "[package]\nname = 1", "package.name: expected string"},
+		{"array type", "[build]\nlibs = \"m\"", "build.libs: expected array of strings"},
+		{"module name", "[dependencies.std]\nversion = \"1\"", "invalid module name: std"},
+		{"missing version", "[dependencies.json]", "dependencies.json.version: missing version"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := load_source(t, test.src)
+			if err == nil {
+				t.Fatalf("expected error %q", test.want)
+			}
+			if !strings.HasSuffix(err.Error(), ": "+test.want) {
+				t.Errorf("got error %q, want %q", err.Error(), test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package manifest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Table is key-value table of TOML document.
+type Table map[string]any
+
+// Parses subset of TOML used by manifest files.
+// Supports tables, comments, strings, integers, booleans and
+// arrays of these values. Keys of root table are stored in "" table.
+func parse_toml(src string) (map[string]Table, error) {
+	tables := map[string]Table{"": {}}
+	table := tables[""]
+	lines := strings.Split(src, "\n")
+	for i := 0; i < len(lines); i++ {
+		row := i + 1
+		line := strings.TrimSpace(strip_comment(lines[i]))
+		if line == "" {
+			continue
+		}
+		if line[0] == '[' {
+			if line[len(line)-1] != ']' {
+				return nil, fmt.Errorf("%d: invalid table header", row)
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if name == "" {
+				return nil, fmt.Errorf("%d: missing table name", row)
+			}
+			if _, exist := tables[name]; exist {
+				return nil, fmt.Errorf("%d: table is already defined: %s", row, name)
+			}
+			table = Table{}
+			tables[name] = table
+			continue
+		}
+		eq := strings.IndexByte(line, '=')
+		if eq == -1 {
+			return nil, fmt.Errorf("%d: expected key = value", row)
+		}
+		key := strings.TrimSpace(line[:eq])
+		if key == "" {
+			return nil, fmt.Errorf("%d: missing key", row)
+		}
+		if key[0] == '"' {
+			unquoted, err := strconv.Unquote(key)
+			if err != nil {
+				return nil, fmt.Errorf("%d: invalid key: %s", row, key)
+			}
+			key = unquoted
+		}
+		if _, exist := table[key]; exist {
+			return nil, fmt.Errorf("%d: key is already defined: %s", row, key)
+		}
+		value := strings.TrimSpace(line[eq+1:])
+		// Arrays may span multiple lines.
+		for strings.HasPrefix(value, "[") && !is_closed_array(value) {
+			i++
+			if i >= len(lines) {
+				return nil, fmt.Errorf("%d: array is not closed", row)
+			}
+			value += " " + strings.TrimSpace(strip_comment(lines[i]))
+		}
+		v, err := parse_toml_value(value)
+		if err != nil {
+			return nil, fmt.Errorf("%d: %s", row, err.Error())
+		}
+		table[key] = v
+	}
+	return tables, nil
+}
+
+// Removes comment of line, ignores comment characters in strings.
+func strip_comment(line string) string {
+	quote := byte(0)
+	for i := 0; i < len(line); i++ {
+		b := line[i]
+		switch {
+		case quote != 0:
+			if b == '\\' && quote == '"' {
+				i++
+			} else if b == quote {
+				quote = 0
+			}
+		case b == '"' || b == '\'':
+			quote = b
+		case b == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func is_closed_array(value string) bool {
+	depth := 0
+	quote := byte(0)
+	for i := 0; i < len(value); i++ {
+		b := value[i]
+		switch {
+		case quote != 0:
+			if b == '\\' && quote == '"' {
+				i++
+			} else if b == quote {
+				quote = 0
+			}
+		case b == '"' || b == '\'':
+			quote = b
+		case b == '[':
+			depth++
+		case b == ']':
+			depth--
+		}
+	}
+	return depth == 0
+}
+
+func parse_toml_value(value string) (any, error) {
+	switch {
+	case value == "":
+		return nil, fmt.Errorf("missing value")
+	case value == "true":
+		return true, nil
+	case value == "false":
+		return false, nil
+	case value[0] == '"':
+		s, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string: %s", value)
+		}
+		return s, nil
+	case value[0] == '\'':
+		if len(value) < 2 || value[len(value)-1] != '\'' {
+			return nil, fmt.Errorf("invalid string: %s", value)
+		}
+		return value[1 : len(value)-1], nil
+	case value[0] == '[':
+		return parse_toml_array(value)
+	}
+	n, err := strconv.ParseInt(strings.ReplaceAll(value, "_", ""), 0, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value: %s", value)
+	}
+	return n, nil
+}
+
+func parse_toml_array(value string) ([]any, error) {
+	if value[len(value)-1] != ']' {
+		return nil, fmt.Errorf("invalid array: %s", value)
+	}
+	value = strings.TrimSpace(value[1 : len(value)-1])
+	var items []any
+	for value != "" {
+		end := array_item_end(value)
+		item := strings.TrimSpace(value[:end])
+		if item != "" {
+			v, err := parse_toml_value(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		if end >= len(value) {
+			break
+		}
+		value = strings.TrimSpace(value[end+1:])
+	}
+	return items, nil
+}
+
+// Returns index of comma that ends first item of array content.
+func array_item_end(value string) int {
+	depth := 0
+	quote := byte(0)
+	for i := 0; i < len(value); i++ {
+		b := value[i]
+		switch {
+		case quote != 0:
+			if b == '\\' && quote == '"' {
+				i++
+			} else if b == quote {
+				quote = 0
+			}
+		case b == '"' || b == '\'':
+			quote = b
+		case b == '[':
+			depth++
+		case b == ']':
+			depth--
+		case b == ',' && depth == 0:
+			return i
+		}
+	}
+	return len(value)
+}
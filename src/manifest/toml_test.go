@@ -0,0 +1,126 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package manifest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseToml(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want map[string]Table
+	}{
+		{
+			name: "empty",
+			src:  "",
+			want: map[string]Table{"": {}},
+		},
+		{
+			name: "root keys",
+			src:  "a = \"x\"\nb = 10\nc = true\nd = 'raw\\n'",
+			want: map[string]Table{"": {"a": "x", "b": int64(10), "c": true, "d": "raw\\n"}},
+		},
+		{
+			name: "tables",
+			src:  "[package]\nname = \"app\"\n\n[build]\nprofile = \"release\"",
+			want: map[string]Table{
+				"":        {},
+				"package": {"name": "app"},
+				"build":   {"profile": "release"},
+			},
+		},
+		{
+			name: "comments",
+			src:  "# comment\nname = \"a#b\" # trailing\n[lint] # table\n",
+			want: map[string]Table{"": {"name": "a#b"}, "lint": {}},
+		},
+		{
+			name: "quoted key",
+			src:  "\"a.b\" = 1",
+			want: map[string]Table{"": {"a.b": int64(1)}},
+		},
+		{
+			name: "integers",
+			src:  "a = 1_000\nb = 0x10\nc = -5",
+			want: map[string]Table{"": {"a": int64(1000), "b": int64(16), "c": int64(-5)}},
+		},
+		{
+			name: "arrays",
+			src:  "a = []\nb = [\"x\", \"y,z\"]\nc = [[1, 2], [3]]",
+			want: map[string]Table{"": {
+				"a": []any(nil),
+				"b": []any{"x", "y,z"},
+				"c": []any{[]any{int64(1), int64(2)}, []any{int64(3)}},
+			}},
+		},
+		{
+			name: "multiline array",
+			src:  "a = [\n  \"x\", # first\n  \"y\",\n]",
+			want: map[string]Table{"": {"a": []any{"x", "y"}}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tables, err := parse_toml(test.src)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tables, test.want) {
+				t.Errorf("got %#v, want %#v", tables, test.want)
+			}
+		})
+	}
+}
+
+func TestParseTomlErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"unclosed header", "[package", "1: invalid table header"},
+		{"missing table name", "[ ]", "1: missing table name"},
+		{"duplicate table", "[a]\n[a]", "2: table is already defined: a"},
+		{"missing equal", "name", "1: expected key = value"},
+		{"missing key", "= 1", "1: missing key"},
+		{"invalid key", "\"a = 1", "1: invalid key: \"a"},
+		{"duplicate key", "a = 1\na = 2", "2: key is already defined: a"},
+		{"missing value", "a =", "1: missing value"},
+		{"invalid string", "a = \"x", "1: invalid string: \"x"},
+		{"invalid value", "a = yes", "1: invalid value: yes"},
+		{"unclosed array", "a = [1,\n2", "1: array is not closed"},
+		{"invalid item", "a = [1, x]", "1: invalid value: x"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parse_toml(test.src)
+			if err == nil {
+				t.Fatalf("expected error %q", test.want)
+			}
+			if err.Error() != test.want {
+				t.Errorf("got error %q, want %q", err.Error(), test.want)
+			}
+		})
+	}
+}
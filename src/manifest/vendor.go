@@ -0,0 +1,384 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package manifest
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Environment variable of local module registry directory.
+// Modules of registry are laid out as <registry>/<name>/<version>,
+// either directory or archive with .tar.gz, .tgz or .zip extension.
+const REGISTRY_ENV = "JANE_REGISTRY"
+
+// Archive extensions supported for modules.
+var ARCHIVE_EXTS = [...]string{".tar.gz", ".tgz", ".zip"}
+
+type vendorer struct {
+	m        *Manifest
+	lock     *Lock
+	registry string
+	staging  string
+	versions map[string]string // Versions of vendored modules by name.
+	locked   Lock
+}
+
+// Copies dependencies of manifest into vendor directory of project
+// and writes lock file. Dependencies of dependencies are vendored too.
+//
+// Content hash of module must match to lock file if version of module
+// is not changed. Modules of local directories are not checked,
+// because they are expected to change during development.
+func Vendor(m *Manifest) (*Lock, error) {
+	lock, err := LoadLock(m.LockPath())
+	if err != nil {
+		return nil, err
+	}
+	staging, err := os.MkdirTemp(m.Root, ".vendor-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+	v := vendorer{
+		m:        m,
+		lock:     lock,
+		registry: os.Getenv(REGISTRY_ENV),
+		staging:  staging,
+		versions: map[string]string{},
+	}
+	err = v.vendor(m.Dependencies)
+	if err != nil {
+		return nil, err
+	}
+	dir := m.VendorDir()
+	err = os.RemoveAll(dir)
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(filepath.Join(staging, VENDOR_DIR), 0o777)
+	if err != nil {
+		return nil, err
+	}
+	err = os.Rename(filepath.Join(staging, VENDOR_DIR), dir)
+	if err != nil {
+		return nil, err
+	}
+	v.locked.sort()
+	err = v.locked.Write(m.LockPath())
+	if err != nil {
+		return nil, err
+	}
+	return &v.locked, nil
+}
+
+func (v *vendorer) vendor(deps []Dependency) error {
+	for _, dep := range deps {
+		if version, ok := v.versions[dep.Name]; ok {
+			if version != dep.Version {
+				return fmt.Errorf("module %s is required with different versions: %q and %q",
+					dep.Name, version, dep.Version)
+			}
+			continue
+		}
+		err := v.vendor_module(dep)
+		if err != nil {
+			return fmt.Errorf("module %s: %s", dep.Name, err.Error())
+		}
+	}
+	return nil
+}
+
+func (v *vendorer) vendor_module(dep Dependency) error {
+	src, source, err := v.fetch(dep)
+	if err != nil {
+		return err
+	}
+	dst := filepath.Join(v.staging, VENDOR_DIR, dep.Name)
+	err = copy_module(src, dst)
+	if err != nil {
+		return err
+	}
+	hash, err := HashDir(dst)
+	if err != nil {
+		return err
+	}
+	locked := v.lock.Module(dep.Name)
+	if dep.Path == "" && locked != nil && locked.Version == dep.Version && locked.Hash != hash {
+		return fmt.Errorf("content hash mismatch for version %s\n\tlocked:   %s\n\tvendored: %s",
+			dep.Version, locked.Hash, hash)
+	}
+	v.locked.Modules = append(v.locked.Modules, LockedModule{
+		Name:    dep.Name,
+		Version: dep.Version,
+		Source:  source,
+		Hash:    hash,
+	})
+	v.versions[dep.Name] = dep.Version
+
+	path := filepath.Join(src, FILE_NAME)
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	m, err := Load(path)
+	if err != nil {
+		return err
+	}
+	return v.vendor(m.Dependencies)
+}
+
+// Returns directory of module content and source to record in lock file.
+// Archives are extracted into staging directory.
+func (v *vendorer) fetch(dep Dependency) (dir string, source string, err error) {
+	switch {
+	case dep.Path != "":
+		info, err := os.Stat(dep.Path)
+		if err != nil {
+			return "", "", err
+		}
+		if !info.IsDir() {
+			return "", "", fmt.Errorf("path is not directory: %s", dep.Path)
+		}
+		return dep.Path, "path+" + v.rel(dep.Path), nil
+	case dep.Archive != "":
+		dir, err = v.extract(dep.Name, dep.Archive)
+		return dir, "archive+" + v.rel(dep.Archive), err
+	}
+	if v.registry == "" {
+		return "", "", fmt.Errorf("module has no path or archive and %s is not set", REGISTRY_ENV)
+	}
+	base := filepath.Join(v.registry, dep.Name, dep.Version)
+	if info, err := os.Stat(base); err == nil && info.IsDir() {
+		return base, "registry", nil
+	}
+	for _, ext := range ARCHIVE_EXTS {
+		if _, err := os.Stat(base + ext); err == nil {
+			dir, err = v.extract(dep.Name, base+ext)
+			return dir, "registry", err
+		}
+	}
+	return "", "", fmt.Errorf("version %s is not found in registry: %s", dep.Version, v.registry)
+}
+
+// Returns path relative to project root if possible.
+func (v *vendorer) rel(path string) string {
+	rel, err := filepath.Rel(v.m.Root, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Extracts archive into staging directory.
+// Returns root directory of module in archive.
+func (v *vendorer) extract(name string, path string) (string, error) {
+	dir := filepath.Join(v.staging, "src", name)
+	err := os.MkdirAll(dir, 0o777)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case strings.HasSuffix(path, ".zip"):
+		err = extract_zip(path, dir)
+	case strings.HasSuffix(path, ".tar.gz"), strings.HasSuffix(path, ".tgz"):
+		err = extract_tar_gz(path, dir)
+	default:
+		err = fmt.Errorf("unsupported archive: %s", path)
+	}
+	if err != nil {
+		return "", err
+	}
+	return archive_root(dir)
+}
+
+// Returns single top-level directory of extracted archive if exist,
+// archives commonly wrap content with directory named by version.
+func archive_root(dir string) (string, error) {
+	dirents, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(dirents) == 1 && dirents[0].IsDir() {
+		return filepath.Join(dir, dirents[0].Name()), nil
+	}
+	return dir, nil
+}
+
+// Returns destination path of archive entry.
+// Entries that escapes destination directory are not allowed.
+func archive_entry_path(dir string, name string) (string, error) {
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if path != dir && !strings.HasPrefix(path, dir+string(os.PathSeparator)) {
+		return "", fmt.Errorf("invalid archive entry: %s", name)
+	}
+	return path, nil
+}
+
+func write_file(path string, r io.Reader) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o777)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func extract_zip(path string, dir string) error {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		dst, err := archive_entry_path(dir, f.Name)
+		if err != nil {
+			return err
+		}
+		if f.FileInfo().IsDir() {
+			err = os.MkdirAll(dst, 0o777)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = write_file(dst, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extract_tar_gz(path string, dir string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	r := tar.NewReader(gz)
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		dst, err := archive_entry_path(dir, h.Name)
+		if err != nil {
+			return err
+		}
+		switch h.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(dst, 0o777)
+		case tar.TypeReg:
+			err = write_file(dst, r)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Copies content of module directory.
+// Entries that are not part of module content are skipped.
+func copy_module(src string, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if skip_module_entry(src, path, d) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o777)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return write_file(target, f)
+	})
+}
+
+// Checks vendored modules of project against lock file.
+// Returns error for missing or modified modules.
+func Verify(m *Manifest) error {
+	lock, err := LoadLock(m.LockPath())
+	if err != nil {
+		return err
+	}
+	for _, dep := range m.Dependencies {
+		if lock.Module(dep.Name) == nil {
+			return fmt.Errorf("module %s is not locked", dep.Name)
+		}
+	}
+	for _, locked := range lock.Modules {
+		hash, err := HashDir(filepath.Join(m.VendorDir(), locked.Name))
+		if err != nil {
+			return fmt.Errorf("module %s: %s", locked.Name, err.Error())
+		}
+		if hash != locked.Hash {
+			return fmt.Errorf("module %s: content hash mismatch\n\tlocked:   %s\n\tvendored: %s",
+				locked.Name, locked.Hash, hash)
+		}
+	}
+	return nil
+}
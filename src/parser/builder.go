@@ -22,6 +22,7 @@ package parser
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/DeRuneLabs/jane"
@@ -32,13 +33,7 @@ import (
 )
 
 func compilerErr(t lexer.Token, key string, args ...any) build.Log {
-	return build.Log{
-		Type:   build.ERR,
-		Row:    t.Row,
-		Column: t.Column,
-		Path:   t.File.Path(),
-		Text:   build.Errorf(key, args...),
-	}
+	return build.Err(t.Row, t.Column, t.EndRow(), t.EndColumn(), t.File.Path(), key, args...)
 }
 
 type block_st struct {
@@ -108,7 +103,7 @@ func (b *builder) buildNode(toks []lexer.Token) {
 }
 
 func (b *builder) Build() {
-	for b.Pos != -1 && !b.Ended() {
+	for !b.Ended() {
 		toks := b.next_builder_st()
 		b.public = toks[0].Id == lexer.ID_PUB
 		if b.public {
@@ -248,7 +243,6 @@ func (b *builder) Enum(toks []lexer.Token) {
 		e.DataType, _ = b.DataType(toks, &i, true)
 		i++
 		if i >= len(toks) {
-			b.stop()
 			b.pusherr(e.Token, "body_not_exist")
 			return
 		}
@@ -257,7 +251,6 @@ func (b *builder) Enum(toks []lexer.Token) {
 	}
 	itemToks := b.getrange(&i, lexer.KND_LBRACE, lexer.KND_RBRACE, &toks)
 	if itemToks == nil {
-		b.stop()
 		b.pusherr(e.Token, "body_not_exist")
 		return
 	} else if i < len(toks) {
@@ -346,7 +339,6 @@ func (b *builder) build_struct(toks []lexer.Token, cpp_linked bool) ast.Struct {
 
 	body_toks := b.getrange(&i, lexer.KND_LBRACE, lexer.KND_RBRACE, &toks)
 	if body_toks == nil {
-		b.stop()
 		b.pusherr(s.Token, "body_not_exist")
 		return s
 	}
@@ -391,7 +383,6 @@ func (b *builder) Trait(toks []lexer.Token) {
 	i := 2
 	bodyToks := b.getrange(&i, lexer.KND_LBRACE, lexer.KND_RBRACE, &toks)
 	if bodyToks == nil {
-		b.stop()
 		b.pusherr(t.Token, "body_not_exist")
 		return
 	}
@@ -407,7 +398,7 @@ func (b *builder) implTraitFns(impl *ast.Impl, toks []lexer.Token) {
 	copy(btoks, b.Tokens)
 	b.Pos = 0
 	b.Tokens = toks
-	for b.Pos != -1 && !b.Ended() {
+	for !b.Ended() {
 		fnToks := b.next_builder_st()
 		tok := fnToks[0]
 		switch tok.Id {
@@ -416,6 +407,9 @@ func (b *builder) implTraitFns(impl *ast.Impl, toks []lexer.Token) {
 			continue
 		case lexer.ID_FN, lexer.ID_UNSAFE:
 			f := b.get_method(fnToks)
+			if f == nil {
+				continue
+			}
 			f.Public = true
 			b.setup_receiver(f, impl.Target.Kind)
 			impl.Tree = append(impl.Tree, ast.Node{Token: f.Token, Data: f})
@@ -432,7 +426,7 @@ func (b *builder) implStruct(impl *ast.Impl, toks []lexer.Token) {
 	copy(btoks, b.Tokens)
 	b.Pos = 0
 	b.Tokens = toks
-	for b.Pos != -1 && !b.Ended() {
+	for !b.Ended() {
 		fnToks := b.next_builder_st()
 		tok := fnToks[0]
 		pub := false
@@ -455,6 +449,9 @@ func (b *builder) implStruct(impl *ast.Impl, toks []lexer.Token) {
 		switch tok.Id {
 		case lexer.ID_FN, lexer.ID_UNSAFE:
 			f := b.get_method(fnToks)
+			if f == nil {
+				continue
+			}
 			f.Public = pub
 			b.setup_receiver(f, impl.Base.Kind)
 			impl.Tree = append(impl.Tree, ast.Node{Token: f.Token, Data: f})
@@ -539,7 +536,6 @@ body:
 	i := 0
 	bodyToks := b.getrange(&i, lexer.KND_LBRACE, lexer.KND_RBRACE, &toks)
 	if bodyToks == nil {
-		b.stop()
 		b.pusherr(impl.Base, "body_not_exist")
 		return
 	}
@@ -681,20 +677,44 @@ func (b *builder) buildUseCppDecl(use *ast.UseDecl, toks []lexer.Token) {
 	use.Path = tok.Kind[1 : len(tok.Kind)-1]
 }
 
+// Returns path of library root by name, empty if not exist.
+// Vendored modules are library roots too.
+func library_root(name string) string {
+	if name == jane.STDLIB {
+		return jane.STDLIB_PATH
+	}
+	for _, path := range jane.LIBRARY_PATHS {
+		if filepath.Base(path) == name {
+			return path
+		}
+	}
+	return ""
+}
+
 func (b *builder) buildUseDecl(use *ast.UseDecl, toks []lexer.Token) {
 	var path strings.Builder
-	path.WriteString(jane.STDLIB_PATH)
-	path.WriteRune(os.PathSeparator)
 	tok := toks[0]
-	isStd := false
 	if tok.Id == lexer.ID_CPP {
 		b.buildUseCppDecl(use, toks)
 		return
 	}
-	if tok.Id != lexer.ID_IDENT || tok.Kind != "std" {
+	root := ""
+	if tok.Id == lexer.ID_IDENT {
+		root = library_root(tok.Kind)
+	}
+	if root == "" {
 		b.pusherr(toks[0], "invalid_syntax")
+		root = jane.STDLIB_PATH
 	}
-	isStd = true
+	rootId := tok.Kind
+	// Root package of module is used by name of module.
+	if len(toks) == 1 && root != jane.STDLIB_PATH {
+		use.LinkString = rootId
+		use.Path = root
+		return
+	}
+	path.WriteString(root)
+	path.WriteRune(os.PathSeparator)
 	if len(toks) < 3 {
 		b.pusherr(tok, "invalid_syntax")
 		return
@@ -762,10 +782,7 @@ func (b *builder) buildUseDecl(use *ast.UseDecl, toks []lexer.Token) {
 		}
 		path.WriteString(tok.Kind)
 	}
-	use.LinkString = tokstoa(toks)
-	if isStd {
-		use.LinkString = "std::" + use.LinkString
-	}
+	use.LinkString = rootId + lexer.KND_DBLCOLON + tokstoa(toks)
 	use.Path = path.String()
 }
 
@@ -856,10 +873,6 @@ func (b *builder) fn_prototype(toks []lexer.Token, i *int, method, anon bool) (f
 	return
 }
 
-func (b *builder) stop() {
-	b.Pos = -1
-}
-
 func (b *builder) Func(toks []lexer.Token, method, anon, prototype bool) (f ast.Fn) {
 	var ok bool
 	i := 0
@@ -873,7 +886,6 @@ func (b *builder) Func(toks []lexer.Token, method, anon, prototype bool) (f ast.
 		return
 	}
 	if i >= len(toks) {
-		b.stop()
 		b.pusherr(f.Token, "body_not_exist")
 		return
 	}
@@ -885,9 +897,7 @@ func (b *builder) Func(toks []lexer.Token, method, anon, prototype bool) (f ast.
 			b.pusherr(toks[i], "invalid_syntax")
 		}
 	} else {
-		b.stop()
 		b.pusherr(f.Token, "body_not_exist")
-		b.Tokens = append(toks, b.Tokens...)
 	}
 	return
 }
@@ -1926,7 +1936,6 @@ func (b *builder) getWhileNextIterProfile(bs *block_st) (s ast.St) {
 	i := len(st_toks)
 	blockToks := b.getrange(&i, lexer.KND_LBRACE, lexer.KND_RBRACE, &bs.toks)
 	if blockToks == nil {
-		b.stop()
 		b.pusherr(iter.Token, "body_not_exist")
 		return
 	}
@@ -1943,7 +1952,6 @@ func (b *builder) commonIterProfile(toks []lexer.Token) (s ast.St) {
 	iter.Token = toks[0]
 	toks = toks[1:]
 	if len(toks) == 0 {
-		b.stop()
 		b.pusherr(iter.Token, "body_not_exist")
 		return
 	}
@@ -1954,7 +1962,6 @@ func (b *builder) commonIterProfile(toks []lexer.Token) (s ast.St) {
 	i := len(exprToks)
 	blockToks := b.getrange(&i, lexer.KND_LBRACE, lexer.KND_RBRACE, &toks)
 	if blockToks == nil {
-		b.stop()
 		b.pusherr(iter.Token, "body_not_exist")
 		return
 	}
@@ -2102,7 +2109,6 @@ func (b *builder) MatchCase(toks []lexer.Token) (s ast.St) {
 	i := len(exprToks)
 	block_toks := b.getrange(&i, lexer.KND_LBRACE, lexer.KND_RBRACE, &toks)
 	if block_toks == nil {
-		b.stop()
 		b.pusherr(m.Token, "body_not_exist")
 		return
 	}
@@ -2140,7 +2146,6 @@ func (b *builder) if_expr(bs *block_st) *ast.If {
 	}
 	blockToks := b.getrange(&i, lexer.KND_LBRACE, lexer.KND_RBRACE, &bs.toks)
 	if blockToks == nil {
-		b.stop()
 		b.pusherr(model.Token, "body_not_exist")
 		return nil
 	}
@@ -2166,7 +2171,6 @@ func (b *builder) conditional_default(bs *block_st) *ast.Else {
 		if i < len(bs.toks) {
 			b.pusherr(model.Token, "else_have_expr")
 		} else {
-			b.stop()
 			b.pusherr(model.Token, "body_not_exist")
 		}
 		return nil
@@ -2326,9 +2330,14 @@ func (b *builder) getrange(i *int, open, close string, toks *[]lexer.Token) []le
 	if b.Ended() {
 		return nil
 	}
+	pos := b.Pos
 	*i = 0
 	*toks = b.next_builder_st()
 	rang = ast.Range(i, open, close, *toks)
+	if rang == nil {
+		// Next statement is not body, leave it to be built itself.
+		b.Pos = pos
+	}
 	return rang
 }
 
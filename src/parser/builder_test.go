@@ -0,0 +1,73 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DeRuneLabs/jane/lexer"
+)
+
+func build_source(t *testing.T, src string) *builder {
+	t.Helper()
+	l := lexer.New(lexer.NewFile("test.jn"))
+	toks := l.LexData([]byte(src))
+	if len(l.Logs) > 0 {
+		t.Fatalf("lex failed: %s", l.Logs[0].Text)
+	}
+	b := new_builder(toks)
+	done := make(chan struct{})
+	go func() {
+		b.Build()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("build does not terminate")
+	}
+	return b
+}
+
+func TestBuildBodylessFunc(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		funcs int
+	}{
+		{"last declaration", "fn main() = 0", 1},
+		{"last declaration without return type", "fn main()", 1},
+		{"followed by declaration", "fn f()\nfn main() {}", 2},
+		{"followed by bodyless declaration", "fn f()\nfn main()", 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := build_source(t, test.src)
+			if len(b.Errors) == 0 || b.Errors[0].Key != "body_not_exist" {
+				t.Fatalf("errors = %v, want body_not_exist", b.Errors)
+			}
+			if len(b.Tree) != test.funcs {
+				t.Errorf("got %d declarations, want %d", len(b.Tree), test.funcs)
+			}
+		})
+	}
+}
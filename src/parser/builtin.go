@@ -22,6 +22,7 @@ package parser
 
 import (
 	"strconv"
+	"sync"
 
 	"github.com/DeRuneLabs/jane/ast"
 	"github.com/DeRuneLabs/jane/lexer"
@@ -632,6 +633,16 @@ func init() {
 		f.Owner = builtinFile
 	}
 
+	init_sys_int_statics()
+}
+
+// Guards target limits of int and uint statics.
+// Statics are shared by all parsers, so limits are set once by first
+// parse, after target is selected, and never rewritten concurrently.
+var sys_int_statics_once sync.Once
+
+// Sets limits of int and uint statics by target bit-size.
+func init_sys_int_statics() {
 	intMax := intStatics.Globals[0]
 	intMin := intStatics.Globals[1]
 	uintMax := uintStatics.Globals[0]
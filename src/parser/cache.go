@@ -0,0 +1,216 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package parser
+
+import (
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/DeRuneLabs/jane"
+	"github.com/DeRuneLabs/jane/ast"
+	"github.com/DeRuneLabs/jane/build"
+	"github.com/DeRuneLabs/jane/lexer"
+)
+
+// Cache keeps lexed files and parsed used packages between parses,
+// so sources that are not changed are not lexed or parsed again.
+//
+// Parsed packages are shared by parses; parser marks their defines
+// as used and records generic instances into them. So cache is for
+// repeated checks, like language server does, not for code generation.
+type Cache struct {
+	mu       sync.Mutex
+	files    map[string]*cached_file
+	packages map[string]*cached_package
+}
+
+type cached_file struct {
+	hash uint64
+	file *lexer.File
+	toks []lexer.Token
+	logs []build.Log
+}
+
+type cached_package struct {
+	hash    uint64
+	defines *ast.Defmap
+	logs    []build.Log // Warnings and notes of package.
+	deps    map[string]*cached_package
+	used    []*ast.UseDecl // Packages used by package, transitively.
+	uses    []*ast.UseDecl // Use declarations of package.
+}
+
+// Cache of parses, nothing is cached if nil.
+var CACHE *Cache
+
+// Returns new empty cache.
+func NewCache() *Cache {
+	return &Cache{
+		files:    map[string]*cached_file{},
+		packages: map[string]*cached_package{},
+	}
+}
+
+func hash_bytes(data []byte) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(data)
+	return h.Sum64()
+}
+
+// Returns hash of jane source files of package directory.
+// Overlay contents of files are used.
+func hash_package_dir(dir string) (uint64, bool) {
+	dirents, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, false
+	}
+	h := fnv.New64a()
+	for _, dirent := range dirents {
+		name := dirent.Name()
+		if dirent.IsDir() ||
+			!strings.HasSuffix(name, jane.EXT) ||
+			!build.IsPassFileAnnotation(name) {
+			continue
+		}
+		data, err := lexer.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return 0, false
+		}
+		_, _ = h.Write([]byte(name))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write(data)
+		_, _ = h.Write([]byte{0})
+	}
+	return h.Sum64(), true
+}
+
+// Returns tokens and logs of file of parser.
+// File is lexed again only if content is changed, file of parser is
+// replaced with cached one so tokens and parser have the same file.
+func (c *Cache) lex(p *Parser) ([]lexer.Token, []build.Log) {
+	path := p.File.Path()
+	data, err := lexer.ReadFile(path)
+	if err != nil {
+		l := lexer.New(p.File)
+		return l.Lex(), l.Logs
+	}
+	hash := hash_bytes(data)
+	c.mu.Lock()
+	f := c.files[path]
+	c.mu.Unlock()
+	if f == nil || f.hash != hash {
+		l := lexer.New(p.File)
+		f = &cached_file{hash: hash, file: p.File, toks: l.LexData(data), logs: l.Logs}
+		c.mu.Lock()
+		c.files[path] = f
+		c.mu.Unlock()
+	}
+	p.File = f.file
+	// Tokens are copied, so parsing never changes cached tokens.
+	return append([]lexer.Token(nil), f.toks...), f.logs
+}
+
+// Reports whether package and all packages used by it are not changed.
+func (pkg *cached_package) is_valid(path string, c *Cache) bool {
+	hash, ok := hash_package_dir(path)
+	if !ok || hash != pkg.hash {
+		return false
+	}
+	for dep_path, dep := range pkg.deps {
+		c.mu.Lock()
+		current := c.packages[dep_path]
+		c.mu.Unlock()
+		// Package is parsed with defines of dep, so dep must be same.
+		if current != dep || !dep.is_valid(dep_path, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns use of declaration from cached package if it is not changed.
+func (c *Cache) use(p *Parser, decl *ast.UseDecl) (*ast.UseDecl, bool) {
+	c.mu.Lock()
+	pkg := c.packages[decl.Path]
+	c.mu.Unlock()
+	if pkg == nil || !pkg.is_valid(decl.Path, c) {
+		return nil, false
+	}
+	for _, used := range pkg.used {
+		p.push_used(used)
+	}
+	u := make_use_from_ast(decl)
+	u.PackageUses = pkg.uses
+	pkg.defines.PushDefines(u.Defines)
+	p.pusherrs(pkg.logs...)
+	p.pushUse(u, decl.Selectors)
+	return u, true
+}
+
+// Stores parsed package of use declaration.
+// Package is not stored if any package used by it is not cached.
+func (c *Cache) store_use(decl *ast.UseDecl, psub *Parser) {
+	hash, ok := hash_package_dir(decl.Path)
+	if !ok {
+		return
+	}
+	pkg := &cached_package{
+		hash:    hash,
+		defines: psub.Defines,
+		uses:    psub.PackageUses(),
+		deps:    map[string]*cached_package{},
+	}
+	for _, l := range psub.Errors {
+		if l.Level != build.ERROR {
+			pkg.logs = append(pkg.logs, l)
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, fp := range *psub.package_files {
+		for _, u := range fp.Uses {
+			pkg.used = append(pkg.used, u)
+			if u.Cpp {
+				continue
+			}
+			dep := c.packages[u.Path]
+			if dep == nil {
+				return
+			}
+			pkg.deps[u.Path] = dep
+			pkg.used = append(pkg.used, dep.used...)
+		}
+	}
+	c.packages[decl.Path] = pkg
+}
+
+// Appends use to used packages if package is not used yet.
+func (p *Parser) push_used(u *ast.UseDecl) {
+	for _, used := range *p.Used {
+		if used.Path == u.Path {
+			return
+		}
+	}
+	*p.Used = append(*p.Used, u)
+}
@@ -25,6 +25,7 @@ import (
 	"strings"
 
 	"github.com/DeRuneLabs/jane/ast"
+	"github.com/DeRuneLabs/jane/build"
 	"github.com/DeRuneLabs/jane/command/jane/gen"
 	"github.com/DeRuneLabs/jane/lexer"
 	"github.com/DeRuneLabs/jane/types"
@@ -50,11 +51,15 @@ type eval struct {
 }
 
 func (e *eval) push_err_tok(tok lexer.Token, err string, args ...any) {
+	e.push_err_tok_notes(tok, nil, err, args...)
+}
+
+func (e *eval) push_err_tok_notes(tok lexer.Token, notes []build.Note, err string, args ...any) {
 	if e.has_error {
 		return
 	}
 	e.has_error = true
-	e.p.pusherrtok(tok, err, args...)
+	e.p.pusherrtok_notes(tok, notes, err, args...)
 }
 
 func (e *eval) eval_toks(toks []lexer.Token) (value, ast.ExprModel) {
@@ -395,7 +400,7 @@ func (e *eval) try_cpp_linked_var(toks []lexer.Token, m *expr_model) (v value, o
 	}
 	def, def_t := e.p.linkById(tok.Kind)
 	if def_t == ' ' {
-		e.push_err_tok(tok, "id_not_exist", tok.Kind)
+		e.push_err_tok_notes(tok, suggest(tok.Kind, e.p.ids_in_scope()), "id_not_exist", tok.Kind)
 		return
 	}
 	m.append_sub(exprNode{tok.Kind})
@@ -765,9 +770,10 @@ func (e *eval) cast_slice(t Type, vt Type, errtok lexer.Token) {
 }
 
 func (e *eval) jt_sub_id(dm *ast.Defmap, id_tok lexer.Token, m *expr_model) (v value) {
+	src := dm
 	i, dm, t := dm.FindById(id_tok.Kind, nil)
 	if i == -1 {
-		e.push_err_tok(id_tok, "obj_have_not_id", id_tok.Kind)
+		e.push_err_tok_notes(id_tok, suggest(id_tok.Kind, src.Ids(nil)), "obj_have_not_id", id_tok.Kind)
 		return
 	}
 	v.lvalue = false
@@ -890,9 +896,10 @@ func (e *eval) type_id(toks []lexer.Token, m *expr_model) (v value) {
 }
 
 func (e *eval) obj_sub_id(dm *ast.Defmap, val value, interior_mut bool, id_tok lexer.Token, m *expr_model) (v value) {
+	src := dm
 	i, dm, t := dm.FindById(id_tok.Kind, id_tok.File)
 	if i == -1 {
-		e.push_err_tok(id_tok, "obj_have_not_id", id_tok.Kind)
+		e.push_err_tok_notes(id_tok, suggest(id_tok.Kind, src.Ids(id_tok.File)), "obj_have_not_id", id_tok.Kind)
 		return
 	}
 	v = val
@@ -960,7 +967,11 @@ func (e *eval) enum_sub_id(val value, idTok lexer.Token, m *expr_model) (v value
 	v.is_type = false
 	item := enum.ItemById(idTok.Kind)
 	if item == nil {
-		e.push_err_tok(idTok, "obj_have_not_id", idTok.Kind)
+		ids := make([]string, len(enum.Items))
+		for i, item := range enum.Items {
+			ids[i] = item.Id
+		}
+		e.push_err_tok_notes(idTok, suggest(idTok.Kind, ids), "obj_have_not_id", idTok.Kind)
 	} else {
 		v.expr = item.ExprTag
 		v.model = get_const_expr_model(v)
@@ -1017,6 +1028,7 @@ func (e *eval) trait_obj_sub_id(val value, idTok lexer.Token, m *expr_model) val
 
 type ns_find interface {
 	NsById(string) *ast.Namespace
+	NsIds() []string
 }
 
 func (e *eval) get_ns(toks *[]lexer.Token) *ast.Defmap {
@@ -1034,7 +1046,7 @@ func (e *eval) get_ns(toks *[]lexer.Token) *ast.Defmap {
 					*toks = (*toks)[i:]
 					return ns.Defines
 				}
-				e.push_err_tok(tok, "namespace_not_exist", tok.Kind)
+				e.push_err_tok_notes(tok, suggest(tok.Kind, prev.NsIds()), "namespace_not_exist", tok.Kind)
 				return nil
 			}
 			prev = src.Defines
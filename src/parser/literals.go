@@ -21,7 +21,6 @@
 package parser
 
 import (
-	"runtime"
 	"strconv"
 	"strings"
 
@@ -67,13 +66,13 @@ func get_num_model(v value) ast.ExprModel {
 	switch t := v.expr.(type) {
 	case uint64:
 		fmt := strconv.FormatUint(t, 10)
-		if build.IsX64(runtime.GOARCH) {
+		if build.IsX64(build.ARCH) {
 			return exprNode{fmt + "LLU"}
 		}
 		return exprNode{fmt + "LU"}
 	case int64:
 		fmt := strconv.FormatInt(t, 10)
-		if build.IsX64(runtime.GOARCH) {
+		if build.IsX64(build.ARCH) {
 			return exprNode{fmt + "LL"}
 		}
 		return exprNode{fmt + "L"}
@@ -296,7 +295,7 @@ func (ve *literal_eval) enum_id(id string, e *Enum) (v value) {
 	if e.Token.Id == lexer.ID_NA {
 		ve.model.append_sub(exprNode{build.OutId(id, 0)})
 	} else {
-		ve.model.append_sub(exprNode{build.OutId(id, e.Token.File.Addr())})
+		ve.model.append_sub(exprNode{build.OutId(id, e.Token.File.Id())})
 	}
 	return
 }
@@ -318,7 +317,7 @@ func (ve *literal_eval) struct_id(id string, s *Struct) (v value) {
 	if s.Token.Id == lexer.ID_NA {
 		ve.model.append_sub(exprNode{build.OutId(id, 0)})
 	} else {
-		ve.model.append_sub(exprNode{build.OutId(id, s.Token.File.Addr())})
+		ve.model.append_sub(exprNode{build.OutId(id, s.Token.File.Id())})
 	}
 	return
 }
@@ -367,6 +366,6 @@ func (ve *literal_eval) id() (_ value, ok bool) {
 		return ve.type_id(id, t)
 	}
 
-	ve.p.eval.push_err_tok(ve.token, "id_not_exist", id)
+	ve.p.eval.push_err_tok_notes(ve.token, suggest(id, ve.p.ids_in_scope()), "id_not_exist", id)
 	return
 }
@@ -68,21 +68,33 @@ type Parser struct {
 	linked_structs   []*ast.Struct
 	allowBuiltin     bool
 	package_files    *[]*Parser
+	allowed          []string // Diagnostic keys allowed by pragma of file.
 	not_package      bool
 	JustDefines      bool
 	NoCheck          bool
 	Used             *[]*ast.UseDecl
 	Uses             []*ast.UseDecl
+	use_decls        []*ast.UseDecl // Use declarations of file as written.
 	Defines          *ast.Defmap
 	Errors           []build.Log
 	File             *File
 }
 
+// Returns tokens of file and logs of lexer.
+// Tokens are taken from cache if cache is set.
+func (p *Parser) lex_file() ([]lexer.Token, []build.Log) {
+	if CACHE != nil {
+		return CACHE.lex(p)
+	}
+	l := lexer.New(p.File)
+	toks := l.Lex()
+	return toks, l.Logs
+}
+
 func (p *Parser) parse_file() {
-	lexer := lexer.New(p.File)
-	toks := lexer.Lex()
-	if lexer.Logs != nil {
-		p.pusherrs(lexer.Logs...)
+	toks, logs := p.lex_file()
+	if logs != nil {
+		p.pusherrs(logs...)
 		return
 	}
 
@@ -91,7 +103,6 @@ func (p *Parser) parse_file() {
 		p.pusherrs(errors...)
 		return
 	}
-
 	if !p.parseTree(tree) {
 		return
 	}
@@ -135,14 +146,13 @@ func (p *Parser) link_package(dirents []fs.DirEntry) {
 
 		fp.parse_file()
 		fp.wg.Wait()
-		if len(fp.Errors) > 0 {
-			p.pusherrs(fp.Errors...)
-			return
-		}
+		// Errors of files are independent, so all files are parsed.
+		p.pusherrs(fp.Errors...)
 	}
 }
 
 func ParsePackage(path string, just_defines bool) (*Parser, string) {
+	sys_int_statics_once.Do(init_sys_int_statics)
 	dirents, err := os.ReadDir(path)
 	if err != nil {
 		return nil, err.Error()
@@ -184,17 +194,42 @@ func (p *Parser) setup_package() {
 }
 
 func (p *Parser) pusherrtok(tok lexer.Token, key string, args ...any) {
-	p.pusherrmsgtok(tok, build.Errorf(key, args...))
+	p.pusherrtok_notes(tok, nil, key, args...)
 }
 
-func (p *Parser) pusherrmsgtok(tok lexer.Token, msg string) {
-	p.Errors = append(p.Errors, build.Log{
-		Type:   build.ERR,
-		Row:    tok.Row,
-		Column: tok.Column,
-		Path:   tok.File.Path(),
-		Text:   msg,
-	})
+// Pushes error with secondary notes such as suggestions.
+func (p *Parser) pusherrtok_notes(tok lexer.Token, notes []build.Note, key string, args ...any) {
+	if p.is_allowed(tok.File, key) {
+		return
+	}
+	log := compilerErr(tok, key, args...)
+	log.Notes = notes
+	p.Errors = append(p.Errors, log)
+}
+
+// Reports whether diagnostic key is silenced for file.
+// Keys are silenced by command-line options or allow pragma of file.
+func (p *Parser) is_allowed(f *File, key string) bool {
+	if _, ok := build.LEVELS[key]; !ok {
+		return false
+	}
+	if build.IsAllowed(key) {
+		return true
+	}
+	if p.package_files == nil {
+		return false
+	}
+	for _, fp := range *p.package_files {
+		if fp.File != f {
+			continue
+		}
+		for _, allowed := range fp.allowed {
+			if allowed == key || allowed == build.LINT_WARNINGS {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 func (p *Parser) pusherrs(errs ...build.Log) {
@@ -202,7 +237,7 @@ func (p *Parser) pusherrs(errs ...build.Log) {
 }
 
 func (p *Parser) PushErr(key string, args ...any) {
-	p.pusherrmsg(build.Errorf(key, args...))
+	p.Errors = append(p.Errors, build.FlatErr(key, args...))
 }
 
 func (p *Parser) pusherrmsg(msg string) {
@@ -218,6 +253,11 @@ func get_tree(toks []lexer.Token) ([]ast.Node, []build.Log) {
 	return r.Tree, r.Errors
 }
 
+// Returns AST of tokens, tree is not checked.
+func BuildTree(toks []lexer.Token) ([]ast.Node, []build.Log) {
+	return get_tree(toks)
+}
+
 func (p *Parser) checkCppUsePath(use *ast.UseDecl) bool {
 	if build.IsStdHeaderPath(use.Path) {
 		return true
@@ -273,7 +313,7 @@ func (p *Parser) pushSelects(u *ast.UseDecl, selectors []lexer.Token) (addNs boo
 			if j >= i {
 				break
 			} else if jid.Kind == id.Kind {
-				p.pusherrtok(id, "exist_id", id.Kind)
+				p.pusherrtok_notes(id, declared_at(jid), "exist_id", id.Kind)
 				i = -1
 				break
 			}
@@ -287,7 +327,7 @@ func (p *Parser) pushSelects(u *ast.UseDecl, selectors []lexer.Token) (addNs boo
 		}
 		i, m, def_t := u.Defines.FindById(id.Kind, p.File)
 		if i == -1 {
-			p.pusherrtok(id, "id_not_exist", id.Kind)
+			p.pusherrtok_notes(id, suggest(id.Kind, u.Defines.Ids(p.File)), "id_not_exist", id.Kind)
 			continue
 		}
 		switch def_t {
@@ -345,10 +385,25 @@ func (p *Parser) WrapPackage() {
 	}
 }
 
+// Returns use declarations of all files of package.
+// Selectors of declarations are as written in files.
+func (p *Parser) PackageUses() []*ast.UseDecl {
+	var uses []*ast.UseDecl
+	for _, fp := range *p.package_files {
+		uses = append(uses, fp.use_decls...)
+	}
+	return uses
+}
+
 func (p *Parser) compilePureUse(ast *ast.UseDecl) (_ *ast.UseDecl, hassErr bool) {
+	if CACHE != nil {
+		if u, ok := CACHE.use(p, ast); ok {
+			return u, false
+		}
+	}
 	dirents, err := os.ReadDir(ast.Path)
 	if err != nil {
-		p.pusherrmsg(err.Error())
+		p.pusherrtok(ast.Token, "use_not_found", ast.Path)
 		return nil, true
 	}
 	for i, dirent := range dirents {
@@ -368,7 +423,7 @@ func (p *Parser) compilePureUse(ast *ast.UseDecl) (_ *ast.UseDecl, hassErr bool)
 		}
 		dirents = dirents[i+1:]
 		psub.link_package(dirents)
-		if len(psub.Errors) > 0 {
+		if build.HasErrors(psub.Errors) {
 			p.pusherrs(psub.Errors...)
 			p.pusherrtok(ast.Token, "use_has_errors")
 			return nil, true
@@ -376,8 +431,12 @@ func (p *Parser) compilePureUse(ast *ast.UseDecl) (_ *ast.UseDecl, hassErr bool)
 
 		psub.parse_file()
 		psub.WrapPackage()
+		if CACHE != nil && !build.HasErrors(psub.Errors) {
+			CACHE.store_use(ast, psub)
+		}
 
 		u := make_use_from_ast(ast)
+		u.PackageUses = psub.PackageUses()
 		psub.Defines.PushDefines(u.Defines)
 		p.pusherrs(psub.Errors...)
 		p.pushUse(u, ast.Selectors)
@@ -399,6 +458,7 @@ func (p *Parser) use_decl(decl *ast.UseDecl, err *bool) {
 		*err = true
 		return
 	}
+	p.use_decls = append(p.use_decls, decl)
 	for _, u := range *p.Used {
 		if decl.Path == u.Path {
 			old := u.FullUse
@@ -429,10 +489,10 @@ func (p *Parser) parseUses(tree *[]ast.Node) (err bool) {
 		node := &(*tree)[i]
 		switch node_t := node.Data.(type) {
 		case ast.UseDecl:
-			p.use_decl(&node_t, &err)
-			if err {
-				return
-			}
+			// Errors of uses are independent, so all uses are checked.
+			failed := false
+			p.use_decl(&node_t, &failed)
+			err = err || failed
 			node.Data = nil
 		case ast.Comment:
 		default:
@@ -547,7 +607,7 @@ func (p *Parser) check_generics(types []*GenericType) {
 func (p *Parser) make_type_alias(alias ast.TypeAlias) *ast.TypeAlias {
 	a := new(ast.TypeAlias)
 	*a = alias
-	alias.Doc = p.doc_text.String()
+	a.Doc = p.doc_text.String()
 	p.doc_text.Reset()
 	return a
 }
@@ -559,7 +619,7 @@ func (p *Parser) Type(alias TypeAlias) {
 	}
 	_, tok, canshadow := p.defined_by_id(alias.Id)
 	if tok.Id != lexer.ID_NA && !canshadow {
-		p.pusherrtok(alias.Token, "exist_id", alias.Id)
+		p.pusherrtok_notes(alias.Token, declared_at(tok), "exist_id", alias.Id)
 		return
 	}
 	p.Defines.Types = append(p.Defines.Types, p.make_type_alias(alias))
@@ -666,7 +726,7 @@ func (p *Parser) Enum(e *Enum) {
 	}
 	_, tok, _ := p.defined_by_id(e.Id)
 	if tok.Id != lexer.ID_NA {
-		p.pusherrtok(e.Token, "exist_id", e.Id)
+		p.pusherrtok_notes(e.Token, declared_at(tok), "exist_id", e.Id)
 		return
 	}
 	e.Doc = p.doc_text.String()
@@ -764,8 +824,8 @@ func (p *Parser) Struct(model Struct) {
 	if lexer.IsIgnoreId(model.Id) {
 		p.pusherrtok(model.Token, "ignore_id")
 		return
-	} else if def, _, _ := p.defined_by_id(model.Id); def != nil {
-		p.pusherrtok(model.Token, "exist_id", model.Id)
+	} else if def, tok, _ := p.defined_by_id(model.Id); def != nil {
+		p.pusherrtok_notes(model.Token, declared_at(tok), "exist_id", model.Id)
 		return
 	}
 	s := p.make_struct(model)
@@ -836,8 +896,8 @@ func (p *Parser) Trait(model ast.Trait) {
 	if lexer.IsIgnoreId(model.Id) {
 		p.pusherrtok(model.Token, "ignore_id")
 		return
-	} else if def, _, _ := p.defined_by_id(model.Id); def != nil {
-		p.pusherrtok(model.Token, "exist_id", model.Id)
+	} else if def, tok, _ := p.defined_by_id(model.Id); def != nil {
+		p.pusherrtok_notes(model.Token, declared_at(tok), "exist_id", model.Id)
 		return
 	}
 	trait := new(ast.Trait)
@@ -867,7 +927,7 @@ func (p *Parser) Trait(model ast.Trait) {
 func (p *Parser) implTrait(model *ast.Impl) {
 	trait_def, _, _ := p.trait_by_id(model.Base.Kind)
 	if trait_def == nil {
-		p.pusherrtok(model.Base, "id_not_exist", model.Base.Kind)
+		p.pusherrtok_notes(model.Base, suggest(model.Base.Kind, p.ids_in_scope()), "id_not_exist", model.Base.Kind)
 		return
 	}
 	trait_def.Used = true
@@ -877,7 +937,7 @@ func (p *Parser) implTrait(model *ast.Impl) {
 	s, _, _ := p.struct_by_id(model.Target.Kind)
 	p.Defines.Side = side
 	if s == nil {
-		p.pusherrtok(model.Target.Token, "id_not_exist", sid)
+		p.pusherrtok_notes(model.Target.Token, suggest(sid, p.ids_in_scope()), "id_not_exist", sid)
 		return
 	}
 	model.Target.Tag = s
@@ -944,7 +1004,7 @@ func (p *Parser) implStruct(model *ast.Impl) {
 	s, _, _ := p.struct_by_id(model.Base.Kind)
 	p.Defines.Side = side
 	if s == nil {
-		p.pusherrtok(model.Base, "id_not_exist", model.Base.Kind)
+		p.pusherrtok_notes(model.Base, suggest(model.Base.Kind, p.ids_in_scope()), "id_not_exist", model.Base.Kind)
 		return
 	}
 	for _, node := range model.Tree {
@@ -962,6 +1022,9 @@ func (p *Parser) implStruct(model *ast.Impl) {
 			sf.Receiver.Token = s.Token
 			sf.Receiver.Tag = s
 			sf.Attributes = p.attributes
+			if ast.HasAttribute(build.ATTR_TEST, sf.Attributes) {
+				p.pusherrtok(sf.Token, "test_fn_is_method", sf.Id)
+			}
 			sf.Doc = p.doc_text.String()
 			sf.Owner = p
 			p.doc_text.Reset()
@@ -1007,6 +1070,10 @@ func (p *Parser) pushNs(identifiers []string) *ast.Namespace {
 }
 
 func (p *Parser) Comment(c ast.Comment) {
+	if strings.HasPrefix(c.Content, lexer.PRAGMA_COMMENT_PREFIX+PRAGMA_ALLOW) {
+		p.push_allow(c)
+		return
+	}
 	if strings.HasPrefix(c.Content, lexer.PRAGMA_COMMENT_PREFIX) {
 		p.PushAttribute(c)
 		return
@@ -1015,6 +1082,28 @@ func (p *Parser) Comment(c ast.Comment) {
 	p.doc_text.WriteByte('\n')
 }
 
+// Pragma of file that silences diagnostics: //jane:allow(key, ...)
+const PRAGMA_ALLOW = "allow"
+
+// Appends diagnostic keys of allow pragma of file.
+func (p *Parser) push_allow(c ast.Comment) {
+	content := c.Content[len(lexer.PRAGMA_COMMENT_PREFIX+PRAGMA_ALLOW):]
+	content = strings.TrimSpace(content)
+	if len(content) < 2 ||
+		content[0] != '(' || content[len(content)-1] != ')' {
+		p.pusherrtok(c.Token, "invalid_pragma_directive")
+		return
+	}
+	for _, key := range strings.Split(content[1:len(content)-1], ",") {
+		key = strings.TrimSpace(key)
+		if !build.IsLint(key) {
+			p.pusherrtok(c.Token, "invalid_lint_key", key)
+			continue
+		}
+		p.allowed = append(p.allowed, key)
+	}
+}
+
 func (p *Parser) PushAttribute(c ast.Comment) {
 	var attr ast.Attribute
 	attr.Tag = c.Content[len(lexer.PRAGMA_COMMENT_PREFIX):]
@@ -1168,7 +1257,7 @@ func (p *Parser) check_ret_variables(f *Fn) {
 func (p *Parser) function(ast Fn) {
 	_, tok, canshadow := p.defined_by_id(ast.Id)
 	if tok.Id != lexer.ID_NA && !canshadow {
-		p.pusherrtok(ast.Token, "exist_id", ast.Id)
+		p.pusherrtok_notes(ast.Token, declared_at(tok), "exist_id", ast.Id)
 	} else if lexer.IsIgnoreId(ast.Id) {
 		p.pusherrtok(ast.Token, "ignore_id")
 	}
@@ -1187,14 +1276,14 @@ func (p *Parser) function(ast Fn) {
 }
 
 func (p *Parser) global(vast Var) {
-	def, _, _ := p.defined_by_id(vast.Id)
+	def, tok, _ := p.defined_by_id(vast.Id)
 	if def != nil {
-		p.pusherrtok(vast.Token, "exist_id", vast.Id)
+		p.pusherrtok_notes(vast.Token, declared_at(tok), "exist_id", vast.Id)
 		return
 	} else {
 		for _, g := range p.Defines.Globals {
 			if vast.Id == g.Id {
-				p.pusherrtok(vast.Token, "exist_id", vast.Id)
+				p.pusherrtok_notes(vast.Token, declared_at(g.Token), "exist_id", vast.Id)
 				return
 			}
 		}
@@ -1385,6 +1474,11 @@ func (p *Parser) NsById(id string) *ast.Namespace {
 	return p.Defines.NsById(id)
 }
 
+// Returns identifiers of namespaces.
+func (p *Parser) NsIds() []string {
+	return p.Defines.NsIds()
+}
+
 func (p *Parser) is_shadowed(id string) bool {
 	def, _, _ := p.block_define_by_id(id)
 	return def != nil
@@ -1763,7 +1857,7 @@ func (p *Parser) check_param_dup(params []ast.Param) (err bool) {
 				break
 			} else if param.Id == jparam.Id {
 				err = true
-				p.pusherrtok(param.Token, "exist_id", param.Id)
+				p.pusherrtok_notes(param.Token, declared_at(jparam.Token), "exist_id", param.Id)
 			}
 		}
 	}
@@ -1826,6 +1920,9 @@ func (p *Parser) parse_fn(f *Fn) (err bool) {
 func (p *Parser) check_fns() {
 	err := false
 	check := func(f *Fn) {
+		if ast.HasAttribute(build.ATTR_TEST, f.Attributes) {
+			p.check_test_fn(f)
+		}
 		if f.BuiltinCaller != nil || len(f.Generics) > 0 {
 			return
 		}
@@ -2506,6 +2603,23 @@ func (p *Parser) checkSolidFuncSpecialCases(f *Fn) {
 	}
 }
 
+// Checks signature of test function.
+// Test functions are called by generated test runner without arguments.
+func (p *Parser) check_test_fn(f *Fn) {
+	if len(f.Params) > 0 {
+		p.pusherrtok(f.Token, "fn_have_parameters", f.Id)
+	}
+	if f.RetType.DataType.Id != types.VOID {
+		p.pusherrtok(f.RetType.DataType.Token, "fn_have_ret", f.Id)
+	}
+	if len(f.Generics) > 0 {
+		p.pusherrtok(f.Token, "test_fn_has_generics", f.Id)
+	}
+	if f.IsUnsafe {
+		p.pusherrtok(f.Token, "fn_is_unsafe", f.Id)
+	}
+}
+
 func (p *Parser) checkNewBlockCustom(b *ast.Block, oldBlockVars []*Var) {
 	b.Gotos = new(ast.Gotos)
 	b.Labels = new(ast.Labels)
@@ -2640,8 +2754,8 @@ func (p *Parser) check_st(i *int) {
 		node.Block = p.nodeBlock
 		*p.nodeBlock.Gotos = append(*p.nodeBlock.Gotos, node)
 	case ast.Label:
-		if find_label_parent(data.Label, p.nodeBlock) != nil {
-			p.pusherrtok(data.Token, "label_exist", data.Label)
+		if label := find_label_parent(data.Label, p.nodeBlock); label != nil {
+			p.pusherrtok_notes(data.Token, declared_at(label.Token), "label_exist", data.Label)
 			break
 		}
 		node := new(ast.Label)
@@ -2895,7 +3009,7 @@ func (p *Parser) checkGotos() {
 	for _, gt := range *p.rootBlock.Gotos {
 		label := find_label(gt.Label, p.rootBlock)
 		if label == nil {
-			p.pusherrtok(gt.Token, "label_not_exist", gt.Label)
+			p.pusherrtok_notes(gt.Token, suggest(gt.Label, label_ids(p.rootBlock)), "label_not_exist", gt.Label)
 			continue
 		}
 		label.Used = true
@@ -3014,9 +3128,9 @@ always:
 }
 
 func (p *Parser) var_st(v *Var) {
-	def, _, canshadow := p.block_define_by_id(v.Id)
+	def, tok, canshadow := p.block_define_by_id(v.Id)
 	if !canshadow && def != nil {
-		p.pusherrtok(v.Token, "exist_id", v.Id)
+		p.pusherrtok_notes(v.Token, declared_at(tok), "exist_id", v.Id)
 		return
 	}
 	*v = *p.variable(*v)
@@ -3323,6 +3437,20 @@ func (p *Parser) conditional(model *ast.Conditional) {
 	}
 }
 
+// Returns names of labels in block and parent blocks.
+func label_ids(b *ast.Block) []string {
+	var ids []string
+	for ; b != nil; b = b.Parent {
+		if b.Labels == nil {
+			continue
+		}
+		for _, label := range *b.Labels {
+			ids = append(ids, label.Label)
+		}
+	}
+	return ids
+}
+
 func find_label_parent(id string, b *ast.Block) *ast.Label {
 	label := find_label(id, b)
 	for label == nil {
@@ -3360,7 +3488,7 @@ func (p *Parser) breakWithLabel(brk *ast.Break) {
 		label = find_label_parent(brk.LabelToken.Kind, p.currentIter.Parent)
 	}
 	if label == nil {
-		p.pusherrtok(brk.LabelToken, "label_not_exist", brk.LabelToken.Kind)
+		p.pusherrtok_notes(brk.LabelToken, suggest(brk.LabelToken.Kind, label_ids(p.nodeBlock)), "label_not_exist", brk.LabelToken.Kind)
 		return
 	} else if label.Index+1 >= len(label.Block.Tree) {
 		p.pusherrtok(brk.LabelToken, "invalid_label")
@@ -3395,7 +3523,7 @@ func (p *Parser) continueWithLabel(cont *ast.Continue) {
 	}
 	label := find_label_parent(cont.LoopLabel.Kind, p.currentIter.Parent)
 	if label == nil {
-		p.pusherrtok(cont.LoopLabel, "label_not_exist", cont.LoopLabel.Kind)
+		p.pusherrtok_notes(cont.LoopLabel, suggest(cont.LoopLabel.Kind, label_ids(p.currentIter.Parent)), "label_not_exist", cont.LoopLabel.Kind)
 		return
 	} else if label.Index+1 >= len(label.Block.Tree) {
 		p.pusherrtok(cont.LoopLabel, "invalid_label")
@@ -21,6 +21,8 @@
 package parser
 
 import (
+	"sort"
+
 	"github.com/DeRuneLabs/jane/ast"
 	"github.com/DeRuneLabs/jane/lexer"
 	"github.com/DeRuneLabs/jane/types"
@@ -83,7 +85,13 @@ func (sap *structArgParser) push_arg() {
 	}
 	pair, ok := (*sap.fmap)[sap.arg.TargetId]
 	if !ok {
-		sap.p.pusherrtok(sap.arg.Token, "id_not_exist", sap.arg.TargetId)
+		ids := make([]string, 0, len(*sap.fmap))
+		for id := range *sap.fmap {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		notes := suggest(sap.arg.TargetId, ids)
+		sap.p.pusherrtok_notes(sap.arg.Token, notes, "fn_not_has_parameter", sap.arg.TargetId)
 		return
 	} else if pair.arg != nil {
 		sap.p.pusherrtok(sap.arg.Token, "already_has_expr", sap.arg.TargetId)
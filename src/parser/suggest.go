@@ -0,0 +1,116 @@
+// Copyright (c) 2024 arfy slowy - DeRuneLabs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package parser
+
+import (
+	"strings"
+
+	"github.com/DeRuneLabs/jane/build"
+	"github.com/DeRuneLabs/jane/lexer"
+)
+
+// Returns edit distance of a and b.
+// Letter case is ignored, so names that only differs by case are close.
+func edit_distance(a string, b string) int {
+	ra := []rune(strings.ToLower(a))
+	rb := []rune(strings.ToLower(b))
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// Returns closest name to id in names, empty if not exist.
+// Names are close if edit distance is at most third of id length.
+func closest_name(id string, names []string) string {
+	limit := len(id) / 3
+	if limit < 1 {
+		limit = 1
+	}
+	closest := ""
+	for _, name := range names {
+		if name == id || lexer.IsIgnoreId(name) {
+			continue
+		}
+		d := edit_distance(id, name)
+		if d <= limit && (closest == "" || d < limit) {
+			limit = d
+			closest = name
+		}
+	}
+	return closest
+}
+
+// Returns did you mean note for id, nil if there is no close name.
+func suggest(id string, names []string) []build.Note {
+	name := closest_name(id, names)
+	if name == "" {
+		return nil
+	}
+	return []build.Note{build.FlatNote("did_you_mean", name)}
+}
+
+// Returns note that reports previous declaration at token.
+func declared_at(tok lexer.Token) []build.Note {
+	if tok.File == nil {
+		return nil
+	}
+	return []build.Note{build.NoteAt(tok.Row, tok.Column, tok.EndColumn(),
+		tok.File.Path(), "previously_declared")}
+}
+
+// Returns identifiers in scope of parser.
+// Block variables, block types, package defines and builtins are included.
+func (p *Parser) ids_in_scope() []string {
+	var ids []string
+	for _, v := range p.block_vars {
+		if v != nil {
+			ids = append(ids, v.Id)
+		}
+	}
+	for _, t := range p.blockTypes {
+		if t != nil {
+			ids = append(ids, t.Id)
+		}
+	}
+	if p.package_files != nil {
+		for _, fp := range *p.package_files {
+			ids = append(ids, fp.Defines.Ids(fp.File)...)
+		}
+	}
+	if p.allowBuiltin {
+		ids = append(ids, Builtin.Ids(nil)...)
+	}
+	return ids
+}
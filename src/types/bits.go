@@ -29,7 +29,9 @@ import (
 
 type bit_checker = func(v string, base int, bit int) bool
 
-const BIT_SIZE = 32 << (^uint(0) >> 63)
+// Bit-size of int and uint kinds for target architecture.
+// Defaults to the host, set by Set_bitsize for cross-compilation.
+var BIT_SIZE = 32 << (^uint(0) >> 63)
 
 var (
 	SYS_INT  string
@@ -177,7 +179,8 @@ func Bitsize_of_uint(x uint64) uint64 {
 	}
 }
 
-func init() {
+func Set_bitsize(bits int) {
+	BIT_SIZE = bits
 	switch BIT_SIZE {
 	case 0b00100000:
 		SYS_INT = TypeKind_I32
@@ -187,3 +190,7 @@ func init() {
 		SYS_UINT = TypeKind_U64
 	}
 }
+
+func init() {
+	Set_bitsize(BIT_SIZE)
+}